@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// versionPrefix starts the header line recording the generator version, see writeDoc.
+const versionPrefix = "// codoc version: "
+
+// diffContext is the number of unchanged lines shown around changes in diffs.
+const diffContext = 3
+
+// checkOutput compares generated output with the contents of an existing file.
+// Differences in the recorded generator version are ignored, so that builds of codoc from
+// different versions agree. Reports whether the file is up to date, printing a diff if not.
+func checkOutput(name string, out []byte) bool {
+	if name == "" || name == "-" {
+		fatalf("cannot use -check when writing to stdout")
+	}
+
+	existing, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		report(message{Level: levelError, File: name, Message: "generated file is missing"})
+		return false
+	}
+	if err != nil {
+		fatalf("cannot read file: %v", err)
+	}
+
+	existing = withVersionOf(existing, out)
+	if bytes.Equal(existing, out) {
+		return true
+	}
+
+	report(message{Level: levelError, File: name, Message: "generated file is out of date"})
+	if !*quiet && utf8.Valid(existing) && utf8.Valid(out) {
+		fmt.Print(diffLines(name, string(existing), string(out)))
+	}
+	return false
+}
+
+// withVersionOf replaces the generator version header line of existing with the one of out,
+// if both have one.
+func withVersionOf(existing, out []byte) []byte {
+	start, end, ok := versionLine(existing)
+	ostart, oend, ook := versionLine(out)
+	if !ok || !ook {
+		return existing
+	}
+
+	b := make([]byte, 0, len(existing))
+	b = append(b, existing[:start]...)
+	b = append(b, out[ostart:oend]...)
+	return append(b, existing[end:]...)
+}
+
+// versionLine locates the generator version header line of generated Go code.
+func versionLine(b []byte) (start, end int, ok bool) {
+	i := bytes.Index(b, []byte("\n"+versionPrefix))
+	if i < 0 {
+		return 0, 0, false
+	}
+	start = i + 1
+	end = bytes.IndexByte(b[start:], '\n')
+	if end < 0 {
+		return start, len(b), true
+	}
+	return start, start + end, true
+}
+
+// diffLines returns a unified diff from before to after, made of a single hunk spanning from
+// the first to the last changed line. This is enough to show what regeneration changes,
+// without the cost of computing a minimal diff of large generated files.
+func diffLines(name, before, after string) string {
+	a, b := splitLines(before), splitLines(after)
+
+	// Trim the common prefix and suffix, keeping some context around the changes
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	start := maxInt(pre-diffContext, 0)
+	endA := minInt(len(a)-suf+diffContext, len(a))
+	endB := minInt(len(b)-suf+diffContext, len(b))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s (generated)\n", name, name)
+	fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(start, endA), hunkRange(start, endB))
+	for _, l := range a[start:pre] {
+		sb.WriteString(" " + l)
+	}
+	for _, l := range a[pre : len(a)-suf] {
+		sb.WriteString("-" + l)
+	}
+	for _, l := range b[pre : len(b)-suf] {
+		sb.WriteString("+" + l)
+	}
+	for _, l := range a[len(a)-suf : endA] {
+		sb.WriteString(" " + l)
+	}
+	return sb.String()
+}
+
+// splitLines splits text into lines, keeping line endings.
+// A missing final newline is added, so that every line prints on its own.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	} else {
+		lines[len(lines)-1] += "\n"
+	}
+	return lines
+}
+
+// hunkRange formats the line range [start, end) of a unified diff hunk header.
+func hunkRange(start, end int) string {
+	if end == start {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, end-start)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after string
+		want          string
+	}{
+		{
+			name:   "changed line",
+			before: "a\nb\nc\n",
+			after:  "a\nB\nc\n",
+			want:   "--- f\n+++ f (generated)\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			name:   "context is limited",
+			before: "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			after:  "1\n2\n3\n4\n5\nx\n7\n8\n9\n",
+			want:   "--- f\n+++ f (generated)\n@@ -3,7 +3,7 @@\n 3\n 4\n 5\n-6\n+x\n 7\n 8\n 9\n",
+		},
+		{
+			name:   "added lines",
+			before: "a\n",
+			after:  "a\nb\nc\n",
+			want:   "--- f\n+++ f (generated)\n@@ -1,1 +1,3 @@\n a\n+b\n+c\n",
+		},
+		{
+			name:   "removed lines",
+			before: "a\nb\nc\n",
+			after:  "c\n",
+			want:   "--- f\n+++ f (generated)\n@@ -1,3 +1,1 @@\n-a\n-b\n c\n",
+		},
+		{
+			name:   "empty before",
+			before: "",
+			after:  "a\n",
+			want:   "--- f\n+++ f (generated)\n@@ -0,0 +1,1 @@\n+a\n",
+		},
+		{
+			name:   "missing final newline",
+			before: "a\nb",
+			after:  "a\nb\n",
+			want:   "--- f\n+++ f (generated)\n@@ -1,2 +1,2 @@\n a\n b\n",
+		},
+		{
+			name:   "separate changes share a hunk",
+			before: "a\n1\n2\n3\n4\n5\n6\n7\nb\n",
+			after:  "A\n1\n2\n3\n4\n5\n6\n7\nB\n",
+			want:   "--- f\n+++ f (generated)\n@@ -1,9 +1,9 @@\n-a\n-1\n-2\n-3\n-4\n-5\n-6\n-7\n-b\n+A\n+1\n+2\n+3\n+4\n+5\n+6\n+7\n+B\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, diffLines("f", tt.before, tt.after))
+		})
+	}
+}
+
+func TestWithVersionOf(t *testing.T) {
+	tests := []struct {
+		name          string
+		existing, out string
+		want          string
+	}{
+		{
+			name:     "version replaced",
+			existing: "// Code generated by codoc. DO NOT EDIT.\n// codoc version: v1.0.0\n\npackage docs\n",
+			out:      "// Code generated by codoc. DO NOT EDIT.\n// codoc version: v1.1.0\n\npackage docs\n",
+			want:     "// Code generated by codoc. DO NOT EDIT.\n// codoc version: v1.1.0\n\npackage docs\n",
+		},
+		{
+			name:     "only the version line is replaced",
+			existing: "// Code generated by codoc. DO NOT EDIT.\n// codoc version: v1.0.0\n\npackage old\n",
+			out:      "// Code generated by codoc. DO NOT EDIT.\n// codoc version: (devel)\n\npackage docs\n",
+			want:     "// Code generated by codoc. DO NOT EDIT.\n// codoc version: (devel)\n\npackage old\n",
+		},
+		{
+			name:     "existing without version",
+			existing: "package docs\n",
+			out:      "// Code generated by codoc. DO NOT EDIT.\n// codoc version: v1.1.0\n\npackage docs\n",
+			want:     "package docs\n",
+		},
+		{
+			name:     "output without version",
+			existing: "// x\n// codoc version: v1.0.0\n",
+			out:      "{}\n",
+			want:     "// x\n// codoc version: v1.0.0\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, string(withVersionOf([]byte(tt.existing), []byte(tt.out))))
+		})
+	}
+}
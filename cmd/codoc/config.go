@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/noonien/codoc/codocgen"
+	"gopkg.in/yaml.v3"
+)
+
+// config describes a declarative documentation generation setup.
+// It is read from a YAML or JSON file, and lists the output files to generate
+// along with the packages documented in each of them.
+type config struct {
+	Package string         `json:"package" yaml:"package"` // Default output file package
+	Format  string         `json:"format" yaml:"format"`   // Default output format
+	Outputs []outputConfig `json:"outputs" yaml:"outputs"` // Output files to generate
+
+	filterConfig `yaml:",inline"` // Default filters for all packages
+}
+
+// outputConfig describes a single generated output file.
+type outputConfig struct {
+	File     string          `json:"file" yaml:"file"`         // Output file, relative to the config file
+	Package  string          `json:"package" yaml:"package"`   // Output file package, overrides the default
+	Format   string          `json:"format" yaml:"format"`     // Output format, overrides the default
+	Registry string          `json:"registry" yaml:"registry"` // Variable holding the *codoc.Registry to register with
+	Compress bool            `json:"compress" yaml:"compress"` // Compress the lazy format's encoded packages, or the json and yaml output
+	Packages []packageConfig `json:"packages" yaml:"packages"` // Packages documented in the output file
+}
+
+// packageConfig describes a package to document, with optional filter overrides.
+type packageConfig struct {
+	Path string `json:"path" yaml:"path"` // Package path, relative to the config file
+
+	filterConfig `yaml:",inline"` // Filter overrides for this package
+}
+
+// filterConfig holds the settings that control what gets included in the documentation.
+// Unset values are inherited from the enclosing configuration.
+type filterConfig struct {
+	Exported     *bool    `json:"exported,omitempty" yaml:"exported,omitempty"`           // Only include exported items
+	DocOnly      *bool    `json:"doc_only,omitempty" yaml:"doc_only,omitempty"`           // Only include documented items
+	SynopsisOnly *bool    `json:"synopsis_only,omitempty" yaml:"synopsis_only,omitempty"` // Only keep synopses
+	Include      []string `json:"include,omitempty" yaml:"include,omitempty"`             // Patterns of qualified IDs to include
+	Exclude      []string `json:"exclude,omitempty" yaml:"exclude,omitempty"`             // Patterns of qualified IDs to exclude
+	Tags         []string `json:"tags,omitempty" yaml:"tags,omitempty"`                   // Build tags
+}
+
+// job is a single output file to generate, resolved from flags or a config file.
+type job struct {
+	out      string   // Output file, empty or "-" for stdout
+	pkgName  string   // Output file package
+	format   string   // Output format
+	compress bool     // Compress encoded packages
+	registry string   // Variable holding the registry to register with, empty for the default one
+	pkgs     []pkgJob // Packages to document
+}
+
+// pkgJob is a package to document along with its generation options.
+type pkgJob struct {
+	path string
+	opts []codocgen.Option
+}
+
+// readConfig reads a config file, picking the decoder based on the file extension.
+// Files ending in .json are read as JSON, everything else as YAML.
+func readConfig(name string) (*config, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	conf := &config{}
+	if strings.EqualFold(filepath.Ext(name), ".json") {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(conf)
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(conf)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse config %q: %v", name, err)
+	}
+
+	return conf, nil
+}
+
+// jobs resolves the config into generation jobs.
+// Relative paths are resolved against dir, the directory of the config file.
+func (c *config) jobs(dir string) ([]job, error) {
+	if len(c.Outputs) == 0 {
+		return nil, fmt.Errorf("no outputs configured")
+	}
+
+	var jobs []job
+	for i, out := range c.Outputs {
+		j := job{
+			out:      out.File,
+			pkgName:  firstNonEmpty(out.Package, c.Package),
+			format:   firstNonEmpty(out.Format, c.Format, "go"),
+			registry: out.Registry,
+			compress: out.Compress,
+		}
+		if j.out == "" {
+			return nil, fmt.Errorf("output %d: missing file", i)
+		}
+		if j.pkgName == "" && isGoFormat(j.format) {
+			return nil, fmt.Errorf("output %q: missing package", out.File)
+		}
+		if len(out.Packages) == 0 {
+			return nil, fmt.Errorf("output %q: no packages configured", out.File)
+		}
+		if j.out != "-" && !filepath.IsAbs(j.out) {
+			j.out = filepath.Join(dir, j.out)
+		}
+
+		for _, pkg := range out.Packages {
+			if pkg.Path == "" {
+				return nil, fmt.Errorf("output %q: package with missing path", out.File)
+			}
+
+			opts := c.filterConfig.merge(pkg.filterConfig).options()
+			path := pkg.Path
+			if isRelPath(path) {
+				path = filepath.Join(dir, path)
+				if !filepath.IsAbs(path) && !isRelPath(path) {
+					path = "./" + path
+				}
+			}
+			j.pkgs = append(j.pkgs, pkgJob{path: path, opts: opts})
+		}
+		jobs = append(jobs, j)
+	}
+
+	return jobs, nil
+}
+
+// merge returns the filter config with the values set in override replacing its own.
+func (f filterConfig) merge(override filterConfig) filterConfig {
+	if override.Exported != nil {
+		f.Exported = override.Exported
+	}
+	if override.DocOnly != nil {
+		f.DocOnly = override.DocOnly
+	}
+	if override.SynopsisOnly != nil {
+		f.SynopsisOnly = override.SynopsisOnly
+	}
+	if override.Include != nil {
+		f.Include = override.Include
+	}
+	if override.Exclude != nil {
+		f.Exclude = override.Exclude
+	}
+	if override.Tags != nil {
+		f.Tags = override.Tags
+	}
+	return f
+}
+
+// options converts the filter config into codocgen options.
+func (f filterConfig) options() []codocgen.Option {
+	var opts []codocgen.Option
+	if f.Exported != nil && *f.Exported {
+		opts = append(opts, codocgen.Exported())
+	}
+	if f.DocOnly != nil && *f.DocOnly {
+		opts = append(opts, codocgen.WithDoc())
+	}
+	if f.SynopsisOnly != nil && *f.SynopsisOnly {
+		opts = append(opts, codocgen.SynopsisOnly())
+	}
+	if len(f.Tags) > 0 {
+		opts = append(opts, codocgen.Tags(f.Tags...))
+	}
+
+	if len(f.Include) > 0 {
+		opts = append(opts, codocgen.IncludeNames(f.Include...))
+	}
+	if len(f.Exclude) > 0 {
+		opts = append(opts, codocgen.ExcludeNames(f.Exclude...))
+	}
+
+	return opts
+}
+
+// isRelPath reports whether path is a relative filesystem path rather than an import path.
+func isRelPath(path string) bool {
+	return path == "." || path == ".." || strings.HasPrefix(path, "./") || strings.HasPrefix(path, "../")
+}
+
+// firstNonEmpty returns the first non-empty string, or an empty string if all are empty.
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
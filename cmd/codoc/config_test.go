@@ -0,0 +1,127 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigJobs(t *testing.T) {
+	tests := []struct {
+		name  string
+		conf  config
+		out   string   // Expected output file of the first job
+		pkg   string   // Expected output package of the first job
+		paths []string // Expected package paths of the first job
+		err   string   // Expected error, if any
+	}{
+		{
+			name:  "relative paths",
+			conf:  config{Package: "docs", Outputs: []outputConfig{{File: "docs.go", Packages: []packageConfig{{Path: "./a"}, {Path: "../b"}, {Path: "."}}}}},
+			out:   filepath.Join("gen", "docs.go"),
+			pkg:   "docs",
+			paths: []string{"./" + filepath.Join("gen", "a"), "./b", "./gen"},
+		},
+		{
+			name:  "import paths and stdout",
+			conf:  config{Outputs: []outputConfig{{File: "-", Package: "main", Packages: []packageConfig{{Path: "example.com/a"}}}}},
+			out:   "-",
+			pkg:   "main",
+			paths: []string{"example.com/a"},
+		},
+		{
+			name:  "absolute output",
+			conf:  config{Package: "docs", Outputs: []outputConfig{{File: "/abs/docs.go", Package: "other", Packages: []packageConfig{{Path: "example.com/a"}}}}},
+			out:   "/abs/docs.go",
+			pkg:   "other",
+			paths: []string{"example.com/a"},
+		},
+		{
+			name:  "data formats need no package",
+			conf:  config{Format: "json", Outputs: []outputConfig{{File: "docs.json", Packages: []packageConfig{{Path: "example.com/a"}}}}},
+			out:   filepath.Join("gen", "docs.json"),
+			paths: []string{"example.com/a"},
+		},
+		{name: "no outputs", conf: config{}, err: "no outputs"},
+		{name: "missing file", conf: config{Package: "docs", Outputs: []outputConfig{{Packages: []packageConfig{{Path: "a"}}}}}, err: "missing file"},
+		{name: "missing package", conf: config{Outputs: []outputConfig{{File: "docs.go", Packages: []packageConfig{{Path: "a"}}}}}, err: "missing package"},
+		{name: "no packages", conf: config{Package: "docs", Outputs: []outputConfig{{File: "docs.go"}}}, err: "no packages"},
+		{name: "missing path", conf: config{Package: "docs", Outputs: []outputConfig{{File: "docs.go", Packages: []packageConfig{{}}}}}, err: "missing path"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobs, err := tt.conf.jobs("gen")
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, jobs, 1)
+
+			j := jobs[0]
+			assert.Equal(t, tt.out, j.out, "Output file mismatch")
+			assert.Equal(t, tt.pkg, j.pkgName, "Output package mismatch")
+			var paths []string
+			for _, p := range j.pkgs {
+				paths = append(paths, p.path)
+			}
+			assert.Equal(t, tt.paths, paths, "Package paths mismatch")
+		})
+	}
+}
+
+func TestFilterConfigMerge(t *testing.T) {
+	yes, no := true, false
+
+	tests := []struct {
+		name     string
+		base     filterConfig
+		override filterConfig
+		want     filterConfig
+	}{
+		{
+			name: "unset values are inherited",
+			base: filterConfig{Exported: &yes, Include: []string{"a"}, Tags: []string{"linux"}},
+			want: filterConfig{Exported: &yes, Include: []string{"a"}, Tags: []string{"linux"}},
+		},
+		{
+			name:     "set values replace",
+			base:     filterConfig{Exported: &yes, DocOnly: &yes, Exclude: []string{"a", "b"}},
+			override: filterConfig{Exported: &no, Exclude: []string{"c"}},
+			want:     filterConfig{Exported: &no, DocOnly: &yes, Exclude: []string{"c"}},
+		},
+		{
+			name:     "empty lists clear",
+			base:     filterConfig{Include: []string{"a"}, Tags: []string{"linux"}},
+			override: filterConfig{Include: []string{}, SynopsisOnly: &yes},
+			want:     filterConfig{Include: []string{}, Tags: []string{"linux"}, SynopsisOnly: &yes},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.base.merge(tt.override))
+		})
+	}
+}
+
+func TestReadConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	name := filepath.Join(dir, "codoc.yaml")
+	require.NoError(t, os.WriteFile(name, []byte("package: docs\nexported: true\noutputs:\n  - file: docs.go\n    packages:\n      - path: ./a\n        exported: false\n"), 0o644))
+	conf, err := readConfig(name)
+	require.NoError(t, err)
+	assert.Equal(t, "docs", conf.Package)
+	assert.True(t, *conf.Exported, "Inline filters should be read")
+	assert.False(t, *conf.Outputs[0].Packages[0].Exported, "Package filters should be read")
+
+	name = filepath.Join(dir, "codoc.json")
+	require.NoError(t, os.WriteFile(name, []byte(`{"package": "docs", "unknown": 1}`), 0o644))
+	_, err = readConfig(name)
+	assert.ErrorContains(t, err, "unknown", "Unknown fields should be rejected")
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// formatSource formats generated Go code like gofmt -s.
+// Syntax errors, usually caused by an invalid package or registry name, are returned
+// along with the offending line of the generated code.
+func formatSource(src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, sourceError(src, err)
+	}
+
+	simplify(file)
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, file); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// sourceError adds the line of src the first syntax error of err is on to the error.
+func sourceError(src []byte, err error) error {
+	var list scanner.ErrorList
+	if !errors.As(err, &list) || len(list) == 0 {
+		return err
+	}
+
+	line := list[0].Pos.Line
+	lines := strings.Split(string(src), "\n")
+	if line < 1 || line > len(lines) {
+		return err
+	}
+	return fmt.Errorf("%v\n\t%d: %s", err, line, strings.TrimSpace(lines[line-1]))
+}
+
+// simplify removes the redundant types of composite literals nested in slice, array
+// and map literals, as gofmt -s does.
+func simplify(file *ast.File) {
+	ast.Inspect(file, func(n ast.Node) bool {
+		lit, ok := n.(*ast.CompositeLit)
+		if !ok {
+			return true
+		}
+
+		var keyType, eltType ast.Expr
+		switch t := lit.Type.(type) {
+		case *ast.ArrayType:
+			eltType = t.Elt
+		case *ast.MapType:
+			keyType, eltType = t.Key, t.Value
+		default:
+			return true
+		}
+
+		for i, elt := range lit.Elts {
+			if kv, ok := elt.(*ast.KeyValueExpr); ok {
+				if keyType != nil {
+					simplifyElt(&kv.Key, keyType)
+				}
+				simplifyElt(&kv.Value, eltType)
+				continue
+			}
+			simplifyElt(&lit.Elts[i], eltType)
+		}
+		return true
+	})
+}
+
+// simplifyElt removes the type of a composite literal element matching the element type
+// of its enclosing literal, turning &T{} into {} for pointer element types.
+func simplifyElt(x *ast.Expr, typ ast.Expr) {
+	switch elt := (*x).(type) {
+	case *ast.CompositeLit:
+		if elt.Type != nil && types.ExprString(elt.Type) == types.ExprString(typ) {
+			elt.Type = nil
+		}
+	case *ast.UnaryExpr:
+		star, ok := typ.(*ast.StarExpr)
+		if !ok || elt.Op != token.AND {
+			return
+		}
+		if lit, ok := elt.X.(*ast.CompositeLit); ok && lit.Type != nil && types.ExprString(lit.Type) == types.ExprString(star.X) {
+			lit.Type = nil
+			*x = lit
+		}
+	}
+}
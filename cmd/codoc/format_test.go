@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatSource(t *testing.T) {
+	gofmt, err := exec.LookPath("gofmt")
+	if err != nil {
+		t.Skip("Skipping test since gofmt is not installed")
+	}
+
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{
+			name: "slice elements",
+			src:  "package p\nvar x = []T{T{A: 1}, T{A: 2}}\n",
+		},
+		{
+			name: "map keys and values",
+			src:  "package p\nvar x = map[K]V{K{1}: V{\"a\"}, K{2}: V{\"b\"}}\n",
+		},
+		{
+			name: "pointer elements",
+			src:  "package p\nvar x = []*T{&T{A: 1}, &T{}}\n",
+		},
+		{
+			name: "qualified types",
+			src:  "package p\nvar x = map[string]codoc.Function{\"F\": codoc.Function{Name: \"F\", Args: []string{\"a\"}}}\n",
+		},
+		{
+			name: "nested literals",
+			src:  "package p\nvar x = codoc.Package{Structs: map[string]codoc.Struct{\"S\": codoc.Struct{Fields: map[string]codoc.Field{\"F\": codoc.Field{Doc: \"d\"}}}}}\n",
+		},
+		{
+			name: "mismatched types are kept",
+			src:  "package p\nvar x = []any{T{}, []U{U{}}}\nvar y = []*T{&U{}}\n",
+		},
+		{
+			name: "arrays",
+			src:  "package p\nvar x = [2][]int{[]int{1}, []int{2}}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := exec.Command(gofmt, "-s")
+			cmd.Stdin = bytes.NewBufferString(tt.src)
+			want, err := cmd.Output()
+			require.NoError(t, err, "gofmt -s failed")
+
+			got, err := formatSource([]byte(tt.src))
+			require.NoError(t, err)
+			assert.Equal(t, string(want), string(got), "Output should match gofmt -s")
+		})
+	}
+}
+
+func TestFormatSourceError(t *testing.T) {
+	_, err := formatSource([]byte("package p\n\nfunc init() {\n\t1docs.Register(x)\n}\n"))
+	assert.ErrorContains(t, err, "4: 1docs.Register(x)", "Errors should quote the offending line")
+}
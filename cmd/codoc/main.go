@@ -4,13 +4,20 @@
 package main
 
 import (
+	"bytes"
+	"compress/gzip"
 	"flag"
 	"fmt"
 	"io"
+	"io/fs"
 	"log"
 	"os"
-	"os/exec"
-	"time"
+	"path/filepath"
+	"regexp"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/alecthomas/repr"
 	"github.com/noonien/codoc"
@@ -19,30 +26,93 @@ import (
 
 // Command-line flags
 var (
-	outFile  = flag.String("out", "", "output file, leave empty to write to stdout")
-	pkgName  = flag.String("pkg", "", "output file package")
-	exported = flag.Bool("e", false, "only register exported functions and structs")
+	outFile    = flag.String("out", "", "output `file`, or - for stdout; defaults to "+defaultOutName+" with the format's extension when run by go generate, and to stdout otherwise")
+	pkgName    = flag.String("pkg", "", "output file package, defaults to $GOPACKAGE when run by go generate")
+	exported   = flag.Bool("e", false, "only register exported functions, structs, fields and methods; embedded fields are exported only if the embedded type is")
+	synopsis   = flag.Bool("synopsis", false, "only keep the synopsis of each item, dropping full documentation")
+	registry   = flag.String("registry", "", "register docs with the *codoc.Registry held by the `var`iable in the output package, instead of the default registry")
+	configFile = flag.String("config", "", "read generation settings from a YAML or JSON `file` instead of flags")
+	outFormat  = flag.String("format", "go", "output `format`: go registers composite literals, lazy registers encoded packages decoded on first use, json and yaml write data files loadable with codoc.LoadJSON and codoc.LoadYAML")
+	check      = flag.Bool("check", false, "do not write output files, exit with status 1 and print a diff if any is out of date")
+	bestEffort = flag.Bool("best-effort", false, "extract what can be parsed from packages with errors, logging the problems found")
+	compress   = flag.Bool("compress", false, "compress encoded packages with the lazy format, or gzip the output with the json and yaml formats")
+	includes   stringList
+	excludes   stringList
 )
 
+func init() {
+	flag.Var(&includes, "include", "only register items whose qualified ID matches the glob or /regexp/ `pattern` (repeatable)")
+	flag.Var(&excludes, "exclude", "do not register items whose qualified ID matches the glob or /regexp/ `pattern` (repeatable)")
+}
+
+// stringList is a flag.Value that collects the values of a repeatable flag.
+type stringList []string
+
+// String implements flag.Value.
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+// Set implements flag.Value, appending the value to the list.
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
 // main is the entry point for the codoc command-line tool.
 // It parses command-line flags, processes the specified packages,
 // and generates documentation in the desired output format.
 func main() {
 	log.SetFlags(0)
-	log.SetOutput(os.Stdout)
+	log.SetOutput(os.Stderr)
 
 	// Parse command-line flags
 	flag.Parse()
-	if len(*pkgName) == 0 {
+	if *verbose && *quiet {
+		flag.Usage()
+		fatalf("-v and -q cannot be used together")
+	}
+
+	var jobs []job
+	if *configFile != "" {
+		jobs = configJobs()
+	} else {
+		jobs = []job{flagJob()}
+	}
+
+	stale := false
+	for _, j := range jobs {
+		out := generate(j)
+		if *check {
+			if !checkOutput(j.out, out) {
+				stale = true
+			}
+			continue
+		}
+		writeOutput(j.out, out)
+	}
+	if stale {
+		os.Exit(1)
+	}
+}
+
+// flagJob builds a generation job from the command-line flags.
+// When run by go generate, the output package defaults to the one of the file holding the
+// directive, and the output file to defaultOutName, so that a bare "//go:generate codoc"
+// documents the current package. The package path always defaults to the current directory.
+func flagJob() job {
+	pkg := firstNonEmpty(*pkgName, os.Getenv("GOPACKAGE"))
+	if len(pkg) == 0 && isGoFormat(*outFormat) {
 		flag.Usage()
-		log.Fatal("missing flag: pkg")
+		fatalf("missing flag: pkg")
+	}
+
+	out := *outFile
+	if out == "" && os.Getenv("GOFILE") != "" {
+		out = defaultOutput(*outFormat, *compress)
 	}
 
-	// Check for package paths
 	paths := flag.Args()
 	if len(paths) == 0 {
-		flag.Usage()
-		log.Fatalf("no package paths specified")
+		paths = []string{"."}
 	}
 
 	// Set up documentation generation options
@@ -50,68 +120,291 @@ func main() {
 	if *exported {
 		opts = append(opts, codocgen.Exported())
 	}
+	if *synopsis {
+		opts = append(opts, codocgen.SynopsisOnly())
+	}
+	if len(includes) > 0 {
+		opts = append(opts, codocgen.IncludeNames(includes...))
+	}
+	if len(excludes) > 0 {
+		opts = append(opts, codocgen.ExcludeNames(excludes...))
+	}
+
+	j := job{out: out, pkgName: pkg, format: *outFormat, compress: *compress, registry: *registry}
+	for _, p := range paths {
+		j.pkgs = append(j.pkgs, pkgJob{path: p, opts: opts})
+	}
+	return j
+}
+
+// defaultOutName is the base name of the output file when run by go generate.
+// The zz prefix sorts generated files after the package's own files.
+const defaultOutName = "zz_codoc"
+
+// defaultOutput returns the name of the output file used when run by go generate.
+func defaultOutput(format string, compress bool) string {
+	switch format {
+	case "json", "yaml":
+		name := defaultOutName + "." + format
+		if compress {
+			name += ".gz"
+		}
+		return name
+	}
+	return defaultOutName + ".go"
+}
+
+// configFlags are the flags that can be used along with -config.
+// The others describe what to generate, which is set in the config file instead.
+var configFlags = map[string]bool{"config": true, "check": true, "best-effort": true, "v": true, "q": true, "diag-json": true}
+
+// configJobs builds the generation jobs described by the config file.
+func configJobs() []job {
+	if flag.NArg() > 0 {
+		flag.Usage()
+		fatalf("package paths cannot be used with -config")
+	}
+
+	var conflicting []string
+	flag.Visit(func(f *flag.Flag) {
+		if !configFlags[f.Name] {
+			conflicting = append(conflicting, "-"+f.Name)
+		}
+	})
+	if len(conflicting) > 0 {
+		flag.Usage()
+		fatalf("%s cannot be used with -config, set them in the config file instead", strings.Join(conflicting, ", "))
+	}
+
+	conf, err := readConfig(*configFile)
+	if err != nil {
+		fatalf("%v", err)
+	}
+
+	jobs, err := conf.jobs(filepath.Dir(*configFile))
+	if err != nil {
+		fatalf("invalid config %q: %v", *configFile, err)
+	}
+	return jobs
+}
+
+// generate extracts the documentation for the packages of a job and returns the contents of its output file.
+func generate(j job) []byte {
+	switch j.format {
+	case "go", "lazy", "json", "yaml":
+	default:
+		fatalf("unsupported output format %q", j.format)
+	}
 
 	// Process each package and extract documentation
 	var pkgs []*codoc.Package
-	for _, p := range flag.Args() {
-		pkg, err := codocgen.FromPath(p, opts...)
+	for _, p := range j.pkgs {
+		opts := p.opts
+		var diags []codocgen.Diagnostic
+		if *bestEffort {
+			opts = append(opts[:len(opts):len(opts)], codocgen.BestEffort(&diags))
+		}
+
+		pkg, err := codocgen.FromPath(p.path, opts...)
+		for _, d := range diags {
+			report(diagMessage(levelWarning, p.path, d))
+		}
+
+		// Report errors with positions one by one
+		if errDiags := codocgen.ErrorDiagnostics(err); len(errDiags) > 0 {
+			for _, d := range errDiags {
+				report(diagMessage(levelError, p.path, d))
+			}
+			fatalf("could not get docs for %q", p.path)
+		}
 		if err != nil {
-			log.Fatalf("could not get docs for %q: %v", p, err)
+			fatalf("could not get docs for %q: %v", p.path, err)
 		}
-		log.Printf("got docs for %s", pkg.Name)
+		infof("got docs for %s", pkg.Name)
 		pkgs = append(pkgs, pkg)
 	}
 
-	// Set up output file
-	var f *os.File
-	if *outFile == "" || *outFile == "-" {
-		f = os.Stdout
-	} else {
-		var err error
-		f, err = os.Create(*outFile)
-		if err != nil {
-			log.Fatalf("cannot create file: %v", err)
+	// Keep the output independent of the order packages are given in
+	sort.SliceStable(pkgs, func(a, b int) bool { return pkgs[a].ID < pkgs[b].ID })
+
+	var out bytes.Buffer
+	if !isGoFormat(j.format) {
+		if err := writeData(&out, j.format, j.compress, pkgs); err != nil {
+			fatalf("cannot write docs: %v", err)
 		}
-		defer f.Close()
+		return out.Bytes()
 	}
 
-	// Set up gofmt to format the output
-	gofmt := exec.Command("gofmt", "-s")
+	if err := writeDoc(&out, j, pkgs); err != nil {
+		fatalf("%v", err)
+	}
+	src, err := formatSource(out.Bytes())
+	if err != nil {
+		fatalf("cannot format generated code for %q: %v", j.out, err)
+	}
+	return src
+}
+
+// writeOutput writes generated output to a file, or to stdout if name is empty or "-".
+// Files are written atomically, through a temporary file renamed over the destination,
+// so that a failed run never leaves a partially written file behind.
+func writeOutput(name string, out []byte) {
+	if name == "" || name == "-" {
+		if _, err := os.Stdout.Write(out); err != nil {
+			fatalf("cannot write output: %v", err)
+		}
+		return
+	}
 
-	fmtw, err := gofmt.StdinPipe()
+	if err := writeFileAtomic(name, out); err != nil {
+		fatalf("cannot write file: %v", err)
+	}
+}
+
+// writeFileAtomic replaces the contents of a file by writing a temporary file in the same
+// directory and renaming it. Existing files keep their permissions, new ones are created
+// with mode 0644.
+func writeFileAtomic(name string, data []byte) (err error) {
+	mode := fs.FileMode(0o644)
+	if info, err := os.Stat(name); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".tmp*")
 	if err != nil {
-		log.Fatalf("cannot get stdin pipe: %v", err)
+		return err
 	}
-	gofmt.Stdout = f
-	gofmt.Stderr = os.Stderr
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
 
-	if err := gofmt.Start(); err != nil {
-		log.Fatalf("cannot start gofmt: %v", err)
+	if _, err := f.Write(data); err != nil {
+		return err
 	}
-	writeDoc(fmtw, pkgs)
-	if err := gofmt.Wait(); err != nil {
-		log.Fatal(err)
+	if err := f.Chmod(mode); err != nil {
+		return err
 	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), name)
 }
 
 // writeDoc generates the Go code to register documentation for packages.
-// It writes the code to the specified writer, which is piped through gofmt.
-// The generated code includes imports and a call to codoc.Register for each package.
-func writeDoc(w io.WriteCloser, pkgs []*codoc.Package) {
-	defer w.Close()
-
-	// Write file header with timestamp
-	fmt.Fprintf(w, "// generated @ %s by gendoc\n", time.Now().Format(time.RFC3339))
-	fmt.Fprintf(w, "package %s\n", *pkgName)
+// It writes unformatted code to the specified writer, see formatSource.
+// The generated code includes imports and a call to codoc.Register for each package,
+// or to the Register method of the job's registry if it is set. The lazy format
+// calls RegisterLazy with the encoded package instead.
+func writeDoc(w io.Writer, j job, pkgs []*codoc.Package) error {
+	// Write the standard generated file header, which must not become the package documentation.
+	// Record the generator version rather than a timestamp, so that output is reproducible
+	fmt.Fprintln(w, codocgen.GeneratedComment)
+	fmt.Fprintf(w, "// codoc version: %s\n", generatorVersion())
 	fmt.Fprintln(w)
-	io.WriteString(w, "import \"github.com/noonien/codoc\"\n")
+	fmt.Fprintf(w, "package %s\n", j.pkgName)
 	fmt.Fprintln(w)
 
+	// Lazily registered packages only refer to codoc through the default registry
+	if j.format != "lazy" || j.registry == "" {
+		io.WriteString(w, "import \"github.com/noonien/codoc\"\n")
+		fmt.Fprintln(w)
+	}
+
 	// Write init function that registers all packages
+	registry := j.registry
+	if registry == "" {
+		registry = "codoc"
+	}
 	io.WriteString(w, "func init() {\n")
 	for _, pkg := range pkgs {
+		if j.format == "lazy" {
+			data, err := codoc.EncodeBinary(*pkg, j.compress)
+			if err != nil {
+				return fmt.Errorf("cannot encode docs for %q: %v", pkg.ID, err)
+			}
+			fmt.Fprintf(w, "\t%s.RegisterLazy(%q, %q, %s)\n", registry, pkg.ID, pkg.Name, strconv.Quote(string(data)))
+			continue
+		}
+
 		docval := repr.String(*pkg, repr.Indent("\t"))
-		fmt.Fprintf(w, "\tcodoc.Register(%s)", docval)
+		fmt.Fprintf(w, "\t%s.Register(%s)\n", registry, docval)
 	}
 	io.WriteString(w, "}\n")
+	return nil
+}
+
+// pseudoVersion matches pseudo-versions, like "v0.0.0-20240102150405-abcdef123456",
+// which the go command also derives from version control for builds of untagged commits.
+var pseudoVersion = regexp.MustCompile(`-(?:[\w.]+\.)?\d{14}-[0-9a-f]{12}$`)
+
+// generatorVersion returns the module version codoc was built from, or "(devel)" if unknown.
+func generatorVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "(devel)"
+	}
+	return buildVersion(info)
+}
+
+// buildVersion returns the main module version of a build.
+// Builds from a local checkout are stamped with versions derived from version control,
+// which change with every commit or edit, so modified builds and pseudo-versions of
+// checkouts are reported as "(devel)" to keep regenerated files stable. Pseudo-versions
+// of downloaded modules, like those run with "go run .../cmd/codoc@<commit>", are kept.
+func buildVersion(info *debug.BuildInfo) string {
+	v := info.Main.Version
+	if v == "" || strings.HasSuffix(v, "+dirty") {
+		return "(devel)"
+	}
+	for _, s := range info.Settings {
+		if s.Key == "vcs.modified" && s.Value == "true" {
+			return "(devel)"
+		}
+		if s.Key == "vcs.revision" && pseudoVersion.MatchString(v) {
+			return "(devel)"
+		}
+	}
+	return v
+}
+
+// writeData writes the documentation for packages as a JSON or YAML document,
+// optionally gzip compressed for loading with codoc.RegisterFS.
+func writeData(w io.Writer, format string, compress bool, pkgs []*codoc.Package) error {
+	vals := make([]codoc.Package, len(pkgs))
+	for i, pkg := range pkgs {
+		vals[i] = *pkg
+	}
+	doc := codoc.NewDocument(vals...)
+
+	var zw *gzip.Writer
+	if compress {
+		zw = gzip.NewWriter(w)
+		w = zw
+	}
+
+	var err error
+	if format == "yaml" {
+		err = codoc.EncodeYAML(w, doc)
+	} else {
+		err = codoc.EncodeJSON(w, doc)
+	}
+	if err != nil {
+		return err
+	}
+
+	if zw != nil {
+		return zw.Close()
+	}
+	return nil
+}
+
+// isGoFormat reports whether an output format generates Go code.
+func isGoFormat(format string) bool {
+	return format == "go" || format == "lazy"
 }
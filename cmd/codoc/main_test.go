@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime/debug"
+	"testing"
+
+	"github.com/noonien/codoc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildVersion(t *testing.T) {
+	local := []debug.BuildSetting{{Key: "vcs", Value: "git"}, {Key: "vcs.revision", Value: "abcdef1234567890"}, {Key: "vcs.modified", Value: "false"}}
+	modified := []debug.BuildSetting{{Key: "vcs", Value: "git"}, {Key: "vcs.revision", Value: "abcdef1234567890"}, {Key: "vcs.modified", Value: "true"}}
+
+	tests := []struct {
+		name     string
+		version  string
+		settings []debug.BuildSetting
+		want     string
+	}{
+		{name: "unknown", version: "", want: "(devel)"},
+		{name: "devel", version: "(devel)", want: "(devel)"},
+		{name: "release", version: "v1.2.3", want: "v1.2.3"},
+		{name: "module pseudo-version", version: "v0.0.0-20240102150405-abcdef123456", want: "v0.0.0-20240102150405-abcdef123456"},
+		{name: "module pseudo-version after tag", version: "v1.2.4-0.20240102150405-abcdef123456", want: "v1.2.4-0.20240102150405-abcdef123456"},
+		{name: "tagged checkout", version: "v1.2.3", settings: local, want: "v1.2.3"},
+		{name: "untagged checkout", version: "v1.2.4-0.20240102150405-abcdef123456", settings: local, want: "(devel)"},
+		{name: "dirty checkout", version: "v0.0.0-20240102150405-abcdef123456+dirty", settings: modified, want: "(devel)"},
+		{name: "modified tagged checkout", version: "v1.2.3", settings: modified, want: "(devel)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := &debug.BuildInfo{Main: debug.Module{Path: "github.com/noonien/codoc", Version: tt.version}, Settings: tt.settings}
+			assert.Equal(t, tt.want, buildVersion(info))
+		})
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "docs.go")
+
+	require.NoError(t, writeFileAtomic(name, []byte("a")))
+	data, err := os.ReadFile(name)
+	require.NoError(t, err)
+	assert.Equal(t, "a", string(data))
+
+	// Existing files keep their permissions
+	require.NoError(t, os.Chmod(name, 0o600))
+	require.NoError(t, writeFileAtomic(name, []byte("b")))
+	info, err := os.Stat(name)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm(), "Permissions should be kept")
+	data, err = os.ReadFile(name)
+	require.NoError(t, err)
+	assert.Equal(t, "b", string(data))
+
+	assertOnlyFiles(t, dir, "docs.go")
+}
+
+func TestWriteFileAtomicFailure(t *testing.T) {
+	dir := t.TempDir()
+
+	// Renaming a file over a non-empty directory fails after the temporary file is written
+	name := filepath.Join(dir, "docs.go")
+	require.NoError(t, os.MkdirAll(filepath.Join(name, "sub"), 0o755))
+	assert.Error(t, writeFileAtomic(name, []byte("a")))
+	assertOnlyFiles(t, dir, "docs.go")
+
+	assert.Error(t, writeFileAtomic(filepath.Join(dir, "missing", "docs.go"), []byte("a")), "Missing directories should fail")
+	assertOnlyFiles(t, dir, "docs.go")
+}
+
+// assertOnlyFiles asserts that dir holds exactly the named entries, and no leftover temporary files.
+func assertOnlyFiles(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+
+	var found []string
+	for _, e := range entries {
+		found = append(found, e.Name())
+	}
+	assert.ElementsMatch(t, names, found, "Temporary files should be removed")
+}
+
+func TestDefaultOutput(t *testing.T) {
+	tests := []struct {
+		format   string
+		compress bool
+		want     string
+	}{
+		{format: "go", want: "zz_codoc.go"},
+		{format: "lazy", want: "zz_codoc.go"},
+		{format: "lazy", compress: true, want: "zz_codoc.go"},
+		{format: "json", want: "zz_codoc.json"},
+		{format: "json", compress: true, want: "zz_codoc.json.gz"},
+		{format: "yaml", want: "zz_codoc.yaml"},
+		{format: "yaml", compress: true, want: "zz_codoc.yaml.gz"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, defaultOutput(tt.format, tt.compress), "Output for format %q, compress %v", tt.format, tt.compress)
+	}
+}
+
+func TestGeneratedCodeCompiles(t *testing.T) {
+	gocmd, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("Skipping test since the go command is not installed")
+	}
+
+	pkg := &codoc.Package{
+		ID:        "example.com/docs",
+		Name:      "docs",
+		Doc:       "Package docs is documented.",
+		Functions: map[string]codoc.Function{"F": {Name: "F", Doc: "F does things.", Args: []string{"a"}}},
+		Structs: map[string]codoc.Struct{
+			"S": {Name: "S", Fields: map[string]codoc.Field{"A": {Name: "A", Comment: "a"}}},
+		},
+	}
+
+	// The packages must be in the main module, directories starting with _ are ignored by ./...
+	dir, err := os.MkdirTemp(".", "_compile")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	var dirs []string
+	for _, format := range []string{"go", "lazy"} {
+		for _, registry := range []string{"", "Docs"} {
+			for _, compress := range []bool{false, true} {
+				j := job{pkgName: "docs", format: format, registry: registry, compress: compress}
+				var buf bytes.Buffer
+				require.NoError(t, writeDoc(&buf, j, []*codoc.Package{pkg}))
+				src, err := formatSource(buf.Bytes())
+				require.NoError(t, err, "Generated code should be valid for %+v", j)
+
+				sub := filepath.Join(dir, fmt.Sprintf("%s_%s_%v", format, registry, compress))
+				require.NoError(t, os.Mkdir(sub, 0o755))
+				require.NoError(t, os.WriteFile(filepath.Join(sub, "zz_codoc.go"), src, 0o644))
+				if registry != "" {
+					decl := "package docs\n\nimport \"github.com/noonien/codoc\"\n\nvar Docs = codoc.NewRegistry()\n"
+					require.NoError(t, os.WriteFile(filepath.Join(sub, "registry.go"), []byte(decl), 0o644))
+				}
+				dirs = append(dirs, "./"+filepath.ToSlash(sub))
+			}
+		}
+	}
+
+	out, err := exec.Command(gocmd, append([]string{"build"}, dirs...)...).CombinedOutput()
+	assert.NoError(t, err, "Generated code should compile:\n%s", out)
+}
+
+// TestGenerateThenCheck tests that output written into the documented package, as a bare
+// "//go:generate codoc" does, is up to date when checked right after being generated
+func TestGenerateThenCheck(t *testing.T) {
+	dir, err := os.MkdirTemp(".", "_check")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.go"), []byte("// Package check is checked.\npackage check\n\n// A does a.\nfunc A() {}\n"), 0o644))
+
+	for _, format := range []string{"go", "lazy"} {
+		j := job{
+			out:     filepath.Join(dir, defaultOutput(format, false)),
+			pkgName: "check",
+			format:  format,
+			pkgs:    []pkgJob{{path: "./" + filepath.ToSlash(dir)}},
+		}
+		writeOutput(j.out, generate(j))
+		assert.True(t, checkOutput(j.out, generate(j)), "Freshly generated %s output should be up to date", format)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/noonien/codoc/codocgen"
+)
+
+// Reporting flags
+var (
+	verbose  = flag.Bool("v", false, "also report progress")
+	quiet    = flag.Bool("q", false, "only report errors")
+	jsonDiag = flag.Bool("diag-json", false, "report errors, warnings and progress on stderr as JSON objects, one per line; see -format for JSON output")
+)
+
+// Message levels, from most to least important.
+const (
+	levelError   = "error"
+	levelWarning = "warning"
+	levelInfo    = "info"
+)
+
+// message is an error, warning or progress message, printed to stderr as text, or as JSON with -diag-json.
+type message struct {
+	Level   string `json:"level"`             // One of the level constants
+	Package string `json:"package,omitempty"` // Path of the package the message is about, as given to codoc
+	File    string `json:"file,omitempty"`    // File the message is about
+	Line    int    `json:"line,omitempty"`    // Line in File, starting at 1
+	Column  int    `json:"column,omitempty"`  // Column in Line, starting at 1
+	Message string `json:"message"`           // Description of the problem or progress
+}
+
+// String formats the message as "package: file:line:column: message", omitting unknown parts.
+func (m message) String() string {
+	prefix := ""
+	if m.Package != "" {
+		prefix = m.Package + ": "
+	}
+	if m.File != "" {
+		prefix += m.File
+		if m.Line > 0 {
+			prefix += ":" + strconv.Itoa(m.Line)
+			if m.Column > 0 {
+				prefix += ":" + strconv.Itoa(m.Column)
+			}
+		}
+		prefix += ": "
+	}
+	return prefix + m.Message
+}
+
+// report prints a message to stderr, unless its level is hidden by -q or the lack of -v.
+func report(m message) {
+	switch m.Level {
+	case levelInfo:
+		if !*verbose {
+			return
+		}
+	case levelWarning:
+		if *quiet {
+			return
+		}
+	}
+
+	if *jsonDiag {
+		data, err := json.Marshal(m)
+		if err == nil {
+			os.Stderr.Write(append(data, '\n'))
+		}
+		return
+	}
+	log.Print(m)
+}
+
+// infof reports progress, only shown with -v.
+func infof(format string, args ...any) {
+	report(message{Level: levelInfo, Message: fmt.Sprintf(format, args...)})
+}
+
+// fatalf reports an error and exits with status 1.
+func fatalf(format string, args ...any) {
+	report(message{Level: levelError, Message: fmt.Sprintf(format, args...)})
+	os.Exit(1)
+}
+
+// diagMessage converts a diagnostic about a package to a message.
+func diagMessage(level, pkg string, d codocgen.Diagnostic) message {
+	return message{
+		Level:   level,
+		Package: pkg,
+		File:    d.Pos.Filename,
+		Line:    d.Pos.Line,
+		Column:  d.Pos.Column,
+		Message: d.Message,
+	}
+}
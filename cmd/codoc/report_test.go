@@ -0,0 +1,25 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageString(t *testing.T) {
+	tests := []struct {
+		msg  message
+		want string
+	}{
+		{msg: message{Level: levelError, Message: "failed"}, want: "failed"},
+		{msg: message{Level: levelWarning, Package: "./a", Message: "failed"}, want: "./a: failed"},
+		{msg: message{Level: levelWarning, File: "a.go", Message: "failed"}, want: "a.go: failed"},
+		{msg: message{Level: levelWarning, Package: "./a", File: "a.go", Line: 3, Message: "failed"}, want: "./a: a.go:3: failed"},
+		{msg: message{Level: levelWarning, File: "a.go", Line: 3, Column: 7, Message: "failed"}, want: "a.go:3:7: failed"},
+		{msg: message{Level: levelWarning, File: "a.go", Column: 7, Message: "failed"}, want: "a.go: failed"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.msg.String())
+	}
+}
@@ -4,7 +4,7 @@
 package codoc
 
 import (
-	"strings"
+	"fmt"
 	"sync"
 )
 
@@ -12,70 +12,266 @@ import (
 // It contains information about the package itself, as well as
 // maps of the functions and structs defined within it.
 type Package struct {
-	ID        string              // Unique identifier for the package
-	Name      string              // Package name
-	Doc       string              // Package documentation string
-	Functions map[string]Function // Map of functions in the package
-	Structs   map[string]Struct   // Map of structs in the package
+	ID        string              `json:"id" yaml:"id"`                                   // Unique identifier for the package
+	Name      string              `json:"name" yaml:"name"`                               // Package name
+	Doc       string              `json:"doc,omitempty" yaml:"doc,omitempty"`             // Package documentation string
+	Synopsis  string              `json:"synopsis,omitempty" yaml:"synopsis,omitempty"`   // First sentence of the package documentation
+	DocFile   string              `json:"doc_file,omitempty" yaml:"doc_file,omitempty"`   // Name of the file holding the package documentation
+	Files     []File              `json:"files,omitempty" yaml:"files,omitempty"`         // Source files of the package, sorted by name
+	Functions map[string]Function `json:"functions,omitempty" yaml:"functions,omitempty"` // Map of functions in the package
+	Structs   map[string]Struct   `json:"structs,omitempty" yaml:"structs,omitempty"`     // Map of structs in the package
+}
+
+// File represents a source file of a package.
+// It includes the file's build constraint and the comments preceding its package clause.
+type File struct {
+	Name       string `json:"name" yaml:"name"`                                 // File name, without directory
+	Doc        string `json:"doc,omitempty" yaml:"doc,omitempty"`               // Leading comments of the file, excluding the package documentation
+	Constraint string `json:"constraint,omitempty" yaml:"constraint,omitempty"` // Build constraint expression, empty if the file has none
 }
 
 // Function represents a Go function with its documentation.
 // It includes the function's name, documentation, and parameter information.
 type Function struct {
-	Name    string   // Function name
-	Doc     string   // Function documentation string
-	Args    []string // List of argument names
-	Results []string // List of result names
+	Name     string   `json:"name" yaml:"name"`                             // Function name
+	Doc      string   `json:"doc,omitempty" yaml:"doc,omitempty"`           // Function documentation string
+	Synopsis string   `json:"synopsis,omitempty" yaml:"synopsis,omitempty"` // First sentence of the function documentation
+	Args     []string `json:"args,omitempty" yaml:"args,omitempty"`         // List of argument names
+	Results  []string `json:"results,omitempty" yaml:"results,omitempty"`   // List of result names
 }
 
 // Struct represents a Go struct with its documentation.
 // It includes the struct's name, documentation, fields, and methods.
 type Struct struct {
-	Name    string              // Struct name
-	Doc     string              // Struct documentation string
-	Fields  map[string]Field    // Map of fields in the struct
-	Methods map[string]Function // Map of methods associated with the struct
+	Name     string              `json:"name" yaml:"name"`                             // Struct name
+	Doc      string              `json:"doc,omitempty" yaml:"doc,omitempty"`           // Struct documentation string
+	Synopsis string              `json:"synopsis,omitempty" yaml:"synopsis,omitempty"` // First sentence of the struct documentation
+	Fields   map[string]Field    `json:"fields,omitempty" yaml:"fields,omitempty"`     // Map of fields in the struct
+	Methods  map[string]Function `json:"methods,omitempty" yaml:"methods,omitempty"`   // Map of methods associated with the struct
 }
 
 // Field represents a field in a struct with its documentation.
 type Field struct {
-	Name    string // Field name
-	Doc     string // Field documentation string
-	Comment string // Inline comment for the field
+	Name     string `json:"name" yaml:"name"`                             // Field name
+	Doc      string `json:"doc,omitempty" yaml:"doc,omitempty"`           // Field documentation string
+	Comment  string `json:"comment,omitempty" yaml:"comment,omitempty"`   // Inline comment for the field
+	Synopsis string `json:"synopsis,omitempty" yaml:"synopsis,omitempty"` // First sentence of the field documentation, or of the comment if there is none
+}
+
+// Kind identifies the kind of an item in a registry.
+type Kind int
+
+// Kinds of registered items.
+const (
+	KindPackage  Kind = iota + 1 // A package
+	KindFunction                 // A package level function
+	KindStruct                   // A struct type
+	KindMethod                   // A method of a struct
+	KindField                    // A field of a struct
+)
+
+// String returns the lowercase name of the kind.
+func (k Kind) String() string {
+	switch k {
+	case KindPackage:
+		return "package"
+	case KindFunction:
+		return "function"
+	case KindStruct:
+		return "struct"
+	case KindMethod:
+		return "method"
+	case KindField:
+		return "field"
+	}
+	return "unknown"
+}
+
+// Registry stores registered documentation and allows looking it up by ID.
+// Separate registries can hold independent sets of documentation, like one per plugin.
+// A Registry is safe for concurrent use, and must be created with NewRegistry.
+type Registry struct {
+	pkgs     map[string]Package     // Registered packages, by ID
+	index    map[string]entry       // Every registered function, struct, method and field, by ID
+	search   searchIndex            // Full-text index of names and documentation
+	lazy     map[string]lazyPackage // Packages registered with RegisterLazy and not decoded yet, by ID
+	lazyErrs map[string]error       // Errors of packages registered with RegisterLazy dropped as corrupt, by ID
+	mu       sync.RWMutex           // Mutex to protect concurrent access to the maps
 }
 
-// Global maps to store registered functions, structs, and packages
-var funcs = map[string]Function{}
-var strucsts = map[string]Struct{}
-var pkgs = map[string]Package{}
-var mu sync.RWMutex // Mutex to protect concurrent access to the maps
+// entry is an item in the registry index.
+// Only the value matching its kind is set.
+type entry struct {
+	kind  Kind     // Kind of the item
+	pkg   string   // ID of the package the item belongs to
+	fn    Function // Function or method documentation
+	st    Struct   // Struct documentation
+	field Field    // Field documentation
+}
+
+// NewRegistry creates a new empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		pkgs:     map[string]Package{},
+		index:    map[string]entry{},
+		search:   newSearchIndex(),
+		lazy:     map[string]lazyPackage{},
+		lazyErrs: map[string]error{},
+	}
+}
+
+// Default is the registry used by the package-level functions, and by generated code.
+var Default = NewRegistry()
 
-// Register adds a package and all its components to the global registry.
+// Register adds a package and all its components to the default registry.
 // It uses the package's ID as a prefix for registering functions and structs.
-func Register(pkg Package) {
-	mu.Lock()
-	defer mu.Unlock()
+func Register(pkg Package) { Default.Register(pkg) }
 
-	id := pkg.ID
-	if pkg.Name == "main" {
-		id = "main"
+// TryRegister adds a package to the default registry, unless its ID is already registered.
+// Returns a *ConflictError if it is.
+func TryRegister(pkg Package) error { return Default.TryRegister(pkg) }
+
+// Replace adds a package to the default registry, removing any package previously registered
+// under the same ID. Reports whether a package was replaced.
+func Replace(pkg Package) bool { return Default.Replace(pkg) }
+
+// Unregister removes a package and all its components from the default registry.
+// Reports whether the package was registered.
+func Unregister(id string) bool { return Default.Unregister(id) }
+
+// GetPackage retrieves a package from the default registry by its ID.
+// Returns nil if the package is not found.
+func GetPackage(id string) *Package { return Default.GetPackage(id) }
+
+// GetFunction retrieves a function from the default registry by its ID.
+// The ID can be either a direct function ID or a struct method ID (pkg.struct.method).
+// Returns nil if the function is not found.
+func GetFunction(id string) *Function { return Default.GetFunction(id) }
+
+// GetStruct retrieves a struct from the default registry by its ID.
+// Returns nil if the struct is not found.
+func GetStruct(id string) *Struct { return Default.GetStruct(id) }
+
+// GetField retrieves a struct field from the default registry by its ID (pkg.struct.field).
+// Returns nil if the struct or the field is not found.
+func GetField(id string) *Field { return Default.GetField(id) }
+
+// ConflictError is returned by TryRegister when a package ID is already registered.
+// This happens when the same package is registered twice, or when two main
+// packages are registered, since they all share the "main" ID.
+type ConflictError struct {
+	ID       string // ID the package is registered under
+	Existing string // Import path of the already registered package
+	New      string // Import path of the package that could not be registered
+}
+
+// Error implements the error interface for ConflictError.
+func (e *ConflictError) Error() string {
+	if e.Existing == e.New {
+		return fmt.Sprintf("codoc: package %q already registered", e.ID)
 	}
-	pkgs[id] = pkg
-	prefix := id + "."
-	for _, fn := range pkg.Functions {
-		funcs[prefix+fn.Name] = fn
+	return fmt.Sprintf("codoc: package %q already registered by %q, cannot register %q", e.ID, e.Existing, e.New)
+}
+
+// Register adds a package and all its components to the registry.
+// It uses the package's ID as a prefix for registering functions and structs,
+// and indexes every function, struct, method and field under its full ID up front,
+// so that lookups never have to walk the package.
+// A package already registered under the same ID is replaced, see TryRegister to detect that.
+func (r *Registry) Register(pkg Package) {
+	r.Replace(pkg)
+}
+
+// TryRegister adds a package and all its components to the registry, unless its ID
+// is already registered, in which case it returns a *ConflictError and leaves the
+// registry unchanged.
+func (r *Registry) TryRegister(pkg Package) error {
+	id := pkgID(pkg)
+	entries := indexPackage(id, pkg)
+	terms := searchTerms(id, pkg)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.pkgs[id]; ok {
+		return &ConflictError{ID: id, Existing: existing.ID, New: pkg.ID}
+	}
+	if existing, ok := r.lazy[id]; ok {
+		return &ConflictError{ID: id, Existing: existing.id, New: pkg.ID}
+	}
+	r.add(id, pkg, entries, terms)
+	return nil
+}
+
+// Replace adds a package and all its components to the registry, after removing the
+// package previously registered under the same ID along with everything derived from it.
+// Reports whether a package was replaced.
+func (r *Registry) Replace(pkg Package) bool {
+	id := pkgID(pkg)
+	entries := indexPackage(id, pkg)
+	terms := searchTerms(id, pkg)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	replaced := r.remove(id)
+	r.add(id, pkg, entries, terms)
+	return replaced
+}
+
+// Unregister removes a package and all its functions, structs, methods and fields from the registry.
+// Reports whether the package was registered.
+func (r *Registry) Unregister(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.remove(id)
+}
+
+// add stores a package, its index entries and search terms. The caller must hold the write lock.
+func (r *Registry) add(id string, pkg Package, entries map[string]entry, terms map[string]map[string]float64) {
+	r.pkgs[id] = pkg
+	delete(r.lazyErrs, id)
+	for eid, e := range entries {
+		r.index[eid] = e
+	}
+	r.search.add(terms)
+}
+
+// remove deletes a package and the index entries it owns. The caller must hold the write lock.
+// Reports whether the package was registered.
+func (r *Registry) remove(id string) bool {
+	delete(r.lazyErrs, id)
+	if _, ok := r.lazy[id]; ok {
+		delete(r.lazy, id)
+		return true
 	}
-	for _, st := range pkg.Structs {
-		strucsts[prefix+st.Name] = st
+
+	pkg, ok := r.pkgs[id]
+	if !ok {
+		return false
 	}
+
+	delete(r.pkgs, id)
+	removed := []string{id}
+	for eid := range indexPackage(id, pkg) {
+		// Leave entries that another package has since taken over
+		if e, ok := r.index[eid]; ok && e.pkg == id {
+			delete(r.index, eid)
+			removed = append(removed, eid)
+		}
+	}
+	r.search.remove(removed...)
+	return true
 }
 
 // GetPackage retrieves a package from the registry by its ID.
 // Returns nil if the package is not found.
-func GetPackage(id string) *Package {
-	mu.RLock()
-	defer mu.RUnlock()
-	pkg, ok := pkgs[id]
+func (r *Registry) GetPackage(id string) *Package {
+	r.loadLazy(id)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	pkg, ok := r.pkgs[id]
 	if !ok {
 		return nil
 	}
@@ -85,48 +281,87 @@ func GetPackage(id string) *Package {
 // GetFunction retrieves a function from the registry by its ID.
 // The ID can be either a direct function ID or a struct method ID (pkg.struct.method).
 // Returns nil if the function is not found.
-func GetFunction(id string) *Function {
-	mu.RLock()
-	defer mu.RUnlock()
-
-	fn, ok := funcs[id]
-	if ok {
-		return &fn
-	}
-	// Find the last dot in the id to split into struct and method parts
-	lastDotIndex := strings.LastIndex(id, ".")
-	if lastDotIndex == -1 {
+func (r *Registry) GetFunction(id string) *Function {
+	e, ok := r.lookup(id)
+	if !ok || (e.kind != KindFunction && e.kind != KindMethod) {
 		return nil
 	}
+	return &e.fn
+}
 
-	// Extract the struct and method names
-	structID := id[:lastDotIndex]
-	methodname := id[lastDotIndex+1:]
-
-	// Get the struct
-	st := GetStruct(structID)
-	if st == nil {
+// GetStruct retrieves a struct from the registry by its ID.
+// Returns nil if the struct is not found.
+func (r *Registry) GetStruct(id string) *Struct {
+	e, ok := r.lookup(id)
+	if !ok || e.kind != KindStruct {
 		return nil
 	}
+	return &e.st
+}
 
-	// Get the method from the struct
-	fn, exists := st.Methods[methodname]
-	if !exists {
+// GetField retrieves a struct field from the registry by its ID (pkg.struct.field).
+// Returns nil if the struct or the field is not found.
+func (r *Registry) GetField(id string) *Field {
+	e, ok := r.lookup(id)
+	if !ok || e.kind != KindField {
 		return nil
 	}
+	return &e.field
+}
 
-	return &fn
+// indexPackage builds the index entries of every function, struct, method and field of a package.
+// Items are identified by their map keys, their Name being informative only.
+func indexPackage(id string, pkg Package) map[string]entry {
+	prefix := id + "."
+	entries := make(map[string]entry, len(pkg.Functions)+len(pkg.Structs))
+	for name, fn := range pkg.Functions {
+		entries[prefix+name] = entry{kind: KindFunction, pkg: id, fn: fn}
+	}
+	for name, st := range pkg.Structs {
+		stID := prefix + name
+		entries[stID] = entry{kind: KindStruct, pkg: id, st: st}
+		for mname, m := range st.Methods {
+			entries[stID+"."+mname] = entry{kind: KindMethod, pkg: id, fn: m}
+		}
+		for fname, f := range st.Fields {
+			entries[stID+"."+fname] = entry{kind: KindField, pkg: id, field: f}
+		}
+	}
+	return entries
 }
 
-// GetStruct retrieves a struct from the registry by its ID.
-// Returns nil if the struct is not found.
-func GetStruct(id string) *Struct {
-	mu.RLock()
-	defer mu.RUnlock()
+// lookup retrieves an entry from the index with a single map read.
+// On a miss, lazily registered packages the ID may belong to are decoded, and the read is retried.
+func (r *Registry) lookup(id string) (entry, bool) {
+	r.mu.RLock()
+	e, ok := r.index[id]
+	r.mu.RUnlock()
+	if ok {
+		return e, ok
+	}
 
-	st, ok := strucsts[id]
-	if !ok {
-		return nil
+	// The ID may belong to a pending package whose ID is any of its prefixes ending before a dot
+	var owners []string
+	for i := 0; i < len(id); i++ {
+		if id[i] == '.' {
+			owners = append(owners, id[:i])
+		}
+	}
+	if !r.loadLazy(owners...) {
+		return e, ok
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok = r.index[id]
+	return e, ok
+}
+
+// pkgID returns the ID a package is registered under.
+// Main packages are always registered as "main", regardless of their import path.
+func pkgID(pkg Package) string {
+	if pkg.Name == "main" {
+		return "main"
 	}
-	return &st
+	return pkg.ID
 }
@@ -1,6 +1,7 @@
 package codoc
 
 import (
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -129,3 +130,248 @@ func TestRegisterMainPackage(t *testing.T) {
 	require.NotNil(t, fn, "GetFunction returned nil for registered main function")
 	assert.Equal(t, "MainFunc", fn.Name, "Function name mismatch")
 }
+
+func TestGetField(t *testing.T) {
+	Register(Package{
+		ID:   "example.com/fieldpkg",
+		Name: "fieldpkg",
+		Structs: map[string]Struct{
+			"FieldStruct": {
+				Name: "FieldStruct",
+				Fields: map[string]Field{
+					"Field1": {Name: "Field1", Doc: "Field1 documentation"},
+				},
+			},
+		},
+	})
+
+	field := GetField("example.com/fieldpkg.FieldStruct.Field1")
+	require.NotNil(t, field, "GetField returned nil for registered field")
+	assert.Equal(t, "Field1 documentation", field.Doc, "Field doc mismatch")
+
+	assert.Nil(t, GetField("example.com/fieldpkg.FieldStruct.Missing"), "GetField should return nil for missing field")
+	assert.Nil(t, GetField("example.com/fieldpkg.Missing.Field1"), "GetField should return nil for missing struct")
+	assert.Nil(t, GetField("nodots"), "GetField should return nil for invalid IDs")
+}
+
+// TestIndexByKey tests that items are registered under their map keys, as docs generated
+// before fields had names never set them
+func TestIndexByKey(t *testing.T) {
+	r := NewRegistry()
+	r.Register(Package{
+		ID:        "example.com/keys",
+		Name:      "keys",
+		Functions: map[string]Function{"F": {Doc: "F does things."}},
+		Structs: map[string]Struct{
+			"S": {
+				Doc:     "S holds things.",
+				Fields:  map[string]Field{"A": {Doc: "a"}, "B": {Comment: "b"}},
+				Methods: map[string]Function{"M": {Doc: "M does things."}},
+			},
+		},
+	})
+
+	assert.NotNil(t, r.GetFunction("example.com/keys.F"), "Functions should be found by key")
+	assert.NotNil(t, r.GetStruct("example.com/keys.S"), "Structs should be found by key")
+	assert.NotNil(t, r.GetFunction("example.com/keys.S.M"), "Methods should be found by key")
+	field := r.GetField("example.com/keys.S.A")
+	require.NotNil(t, field, "Fields should be found by key")
+	assert.Equal(t, "a", field.Doc)
+	assert.Nil(t, r.GetField("example.com/keys.S."), "Nothing should be registered under an empty name")
+
+	var ids, names []string
+	for _, e := range r.Entries() {
+		ids = append(ids, e.ID)
+		names = append(names, e.Name)
+	}
+	assert.Equal(t, []string{"example.com/keys.F", "example.com/keys.S", "example.com/keys.S.A", "example.com/keys.S.B", "example.com/keys.S.M"}, ids, "Entries should be identified by key")
+	assert.Equal(t, []string{"F", "S", "A", "B", "M"}, names, "Entry names should fall back to the key")
+
+	results := r.Search("B")
+	require.NotEmpty(t, results, "Search should match names from keys")
+	assert.Equal(t, "example.com/keys.S.B", results[0].ID)
+
+	require.True(t, r.Unregister("example.com/keys"))
+	assert.Nil(t, r.GetField("example.com/keys.S.A"), "Unregistering should remove entries by key")
+}
+
+func TestRegistryIsolation(t *testing.T) {
+	r1, r2 := NewRegistry(), NewRegistry()
+
+	r1.Register(Package{
+		ID:   "example.com/isolated",
+		Name: "isolated",
+		Functions: map[string]Function{
+			"Func": {Name: "Func", Doc: "Func in r1"},
+		},
+	})
+	r2.Register(Package{
+		ID:   "example.com/isolated",
+		Name: "isolated",
+		Functions: map[string]Function{
+			"Func": {Name: "Func", Doc: "Func in r2"},
+		},
+	})
+
+	fn := r1.GetFunction("example.com/isolated.Func")
+	require.NotNil(t, fn, "GetFunction returned nil for function in r1")
+	assert.Equal(t, "Func in r1", fn.Doc, "Function doc mismatch in r1")
+
+	fn = r2.GetFunction("example.com/isolated.Func")
+	require.NotNil(t, fn, "GetFunction returned nil for function in r2")
+	assert.Equal(t, "Func in r2", fn.Doc, "Function doc mismatch in r2")
+
+	// Neither registration should leak into the default registry
+	assert.Nil(t, GetPackage("example.com/isolated"), "Package should not be in the default registry")
+	assert.Nil(t, GetFunction("example.com/isolated.Func"), "Function should not be in the default registry")
+}
+
+func TestConcurrentMethodLookup(t *testing.T) {
+	r := NewRegistry()
+	pkg := Package{
+		ID:   "example.com/concurrent",
+		Name: "concurrent",
+		Structs: map[string]Struct{
+			"Struct": {
+				Name: "Struct",
+				Methods: map[string]Function{
+					"Method": {Name: "Method", Doc: "Method documentation"},
+				},
+			},
+		},
+	}
+	r.Register(pkg)
+
+	// Method lookups must not take the read lock twice, otherwise a waiting
+	// writer would deadlock them
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				fn := r.GetFunction("example.com/concurrent.Struct.Method")
+				assert.NotNil(t, fn, "GetFunction returned nil for registered method")
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for j := 0; j < 1000; j++ {
+			r.Register(pkg)
+		}
+	}()
+	wg.Wait()
+}
+
+func TestIndexKinds(t *testing.T) {
+	r := NewRegistry()
+	r.Register(Package{
+		ID:   "example.com/kinds",
+		Name: "kinds",
+		Functions: map[string]Function{
+			"Func": {Name: "Func"},
+		},
+		Structs: map[string]Struct{
+			"Struct": {
+				Name:    "Struct",
+				Fields:  map[string]Field{"Field": {Name: "Field"}},
+				Methods: map[string]Function{"Method": {Name: "Method"}},
+			},
+		},
+	})
+
+	// Lookups only return items of the requested kind
+	assert.NotNil(t, r.GetFunction("example.com/kinds.Func"), "Function should be found")
+	assert.NotNil(t, r.GetFunction("example.com/kinds.Struct.Method"), "Method should be found")
+	assert.Nil(t, r.GetFunction("example.com/kinds.Struct"), "Struct should not be found as a function")
+	assert.Nil(t, r.GetFunction("example.com/kinds.Struct.Field"), "Field should not be found as a function")
+	assert.Nil(t, r.GetStruct("example.com/kinds.Func"), "Function should not be found as a struct")
+	assert.Nil(t, r.GetField("example.com/kinds.Struct.Method"), "Method should not be found as a field")
+	assert.NotNil(t, r.GetField("example.com/kinds.Struct.Field"), "Field should be found")
+
+	assert.Equal(t, "method", KindMethod.String(), "Kind name mismatch")
+	assert.Equal(t, "unknown", Kind(0).String(), "Unknown kind name mismatch")
+}
+
+func TestReplaceRemovesStaleEntries(t *testing.T) {
+	r := NewRegistry()
+	r.Register(Package{
+		ID:   "example.com/stale",
+		Name: "stale",
+		Functions: map[string]Function{
+			"Old": {Name: "Old"},
+		},
+		Structs: map[string]Struct{
+			"Struct": {
+				Name:    "Struct",
+				Methods: map[string]Function{"OldMethod": {Name: "OldMethod"}},
+			},
+		},
+	})
+
+	replaced := r.Replace(Package{
+		ID:   "example.com/stale",
+		Name: "stale",
+		Functions: map[string]Function{
+			"New": {Name: "New"},
+		},
+	})
+	assert.True(t, replaced, "Replace should report the replaced package")
+
+	assert.NotNil(t, r.GetFunction("example.com/stale.New"), "New function should be registered")
+	assert.Nil(t, r.GetFunction("example.com/stale.Old"), "Stale function should be removed")
+	assert.Nil(t, r.GetStruct("example.com/stale.Struct"), "Stale struct should be removed")
+	assert.Nil(t, r.GetFunction("example.com/stale.Struct.OldMethod"), "Stale method should be removed")
+
+	assert.False(t, r.Replace(Package{ID: "example.com/fresh", Name: "fresh"}), "Replace should report new packages")
+}
+
+func TestTryRegisterConflicts(t *testing.T) {
+	r := NewRegistry()
+	require.NoError(t, r.TryRegister(Package{ID: "example.com/cmd/a", Name: "main"}), "First main package should register")
+
+	// A second main package collides under the "main" ID
+	err := r.TryRegister(Package{
+		ID:        "example.com/cmd/b",
+		Name:      "main",
+		Functions: map[string]Function{"B": {Name: "B"}},
+	})
+	var conflict *ConflictError
+	require.ErrorAs(t, err, &conflict, "Second main package should conflict")
+	assert.Equal(t, "main", conflict.ID, "Conflict ID mismatch")
+	assert.Equal(t, "example.com/cmd/a", conflict.Existing, "Existing package mismatch")
+	assert.Equal(t, "example.com/cmd/b", conflict.New, "New package mismatch")
+	assert.Contains(t, err.Error(), "example.com/cmd/a", "Error should mention the existing package")
+
+	// The registry is left unchanged
+	assert.Equal(t, "example.com/cmd/a", r.GetPackage("main").ID, "Existing package should be kept")
+	assert.Nil(t, r.GetFunction("main.B"), "Conflicting package should not be indexed")
+}
+
+func TestUnregister(t *testing.T) {
+	r := NewRegistry()
+	r.Register(Package{
+		ID:   "example.com/gone",
+		Name: "gone",
+		Functions: map[string]Function{
+			"Func": {Name: "Func"},
+		},
+		Structs: map[string]Struct{
+			"Struct": {
+				Name:   "Struct",
+				Fields: map[string]Field{"Field": {Name: "Field"}},
+			},
+		},
+	})
+
+	assert.True(t, r.Unregister("example.com/gone"), "Unregister should report the removed package")
+	assert.Nil(t, r.GetPackage("example.com/gone"), "Package should be removed")
+	assert.Nil(t, r.GetFunction("example.com/gone.Func"), "Function should be removed")
+	assert.Nil(t, r.GetStruct("example.com/gone.Struct"), "Struct should be removed")
+	assert.Nil(t, r.GetField("example.com/gone.Struct.Field"), "Field should be removed")
+
+	assert.False(t, r.Unregister("example.com/gone"), "Unregister should report missing packages")
+	assert.NoError(t, r.TryRegister(Package{ID: "example.com/gone", Name: "gone"}), "Unregistered ID should be free again")
+}
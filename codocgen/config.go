@@ -3,6 +3,9 @@
 package codocgen
 
 import (
+	"fmt"
+	"regexp"
+	"strings"
 	"unicode"
 	"unicode/utf8"
 
@@ -14,17 +17,42 @@ import (
 type Option func(*config)
 
 // config holds the configuration for the documentation generator.
-// It contains filters for functions and structs to determine what gets included in the documentation.
+// It contains filters for functions, structs, fields and methods to determine what gets included in the documentation.
 type config struct {
 	funcFilter   []func(fn codoc.Function) bool // Filters for functions
 	structFilter []func(st codoc.Struct) bool   // Filters for structs
+	fieldFilter  []func(f codoc.Field) bool     // Filters for struct fields
+	methodFilter []func(m codoc.Function) bool  // Filters for struct methods
+	userFuncs    []func(fn codoc.Function) bool // Filters set with FilterFuncs, also applied to methods
+	userMethods  bool                           // Whether FilterMethods was used, leaving methods to its filters
+	tags         []string                       // Build tags used to select package files
+	include      []*regexp.Regexp               // Patterns of qualified IDs to include
+	exclude      []*regexp.Regexp               // Patterns of qualified IDs to exclude
+	synopsisOnly bool                           // Only keep synopses, dropping full documentation
+	bestEffort   bool                           // Extract what can be parsed from packages with errors
+	diags        *[]Diagnostic                  // Where problems are reported in best effort mode
+	err          error                          // First error encountered while applying options
+}
+
+// newConfig applies the options to a new config, returning the first error they encountered.
+func newConfig(opts []Option) (*config, error) {
+	conf := &config{}
+	for _, opt := range opts {
+		opt(conf)
+	}
+	if conf.err != nil {
+		return nil, conf.err
+	}
+	return conf, nil
 }
 
 // FilterFuncs adds a function filter to the configuration.
 // The filter function takes a Function and returns true if it should be included in the documentation.
+// Struct methods are filtered too, unless FilterMethods is used to filter them separately.
 func FilterFuncs(fn func(fn codoc.Function) bool) Option {
 	return func(c *config) {
 		c.funcFilter = append(c.funcFilter, fn)
+		c.userFuncs = append(c.userFuncs, fn)
 	}
 }
 
@@ -36,24 +64,51 @@ func FilterStructs(fn func(st codoc.Struct) bool) Option {
 	}
 }
 
-// Exported returns an Option that filters to include only exported functions and structs.
-// Exported items are those that start with an uppercase letter.
+// FilterFields adds a struct field filter to the configuration.
+// The filter function takes a Field and returns true if it should be included in the documentation.
+// Embedded fields are named after their type, without package qualifier or pointer.
+func FilterFields(fn func(f codoc.Field) bool) Option {
+	return func(c *config) {
+		c.fieldFilter = append(c.fieldFilter, fn)
+	}
+}
+
+// FilterMethods adds a struct method filter to the configuration.
+// The filter function takes a Function and returns true if it should be included in the documentation.
+// Once a method filter is set, filters set with FilterFuncs no longer apply to methods.
+func FilterMethods(fn func(m codoc.Function) bool) Option {
+	return func(c *config) {
+		c.methodFilter = append(c.methodFilter, fn)
+		c.userMethods = true
+	}
+}
+
+// Exported returns an Option that filters to include only exported functions, structs, fields and methods.
+// Exported items are those that start with an uppercase letter. Embedded fields are
+// exported only if the embedded type is.
 func Exported() Option {
 	return func(c *config) {
 		c.funcFilter = append(c.funcFilter, func(fn codoc.Function) bool {
-			r, _ := utf8.DecodeRuneInString(fn.Name)
-			return unicode.IsUpper(r)
+			return isExported(fn.Name)
 		})
 
 		c.structFilter = append(c.structFilter, func(st codoc.Struct) bool {
-			r, _ := utf8.DecodeRuneInString(st.Name)
-			return unicode.IsUpper(r)
+			return isExported(st.Name)
+		})
+
+		c.fieldFilter = append(c.fieldFilter, func(f codoc.Field) bool {
+			return isExported(f.Name)
+		})
+
+		c.methodFilter = append(c.methodFilter, func(m codoc.Function) bool {
+			return isExported(m.Name)
 		})
 	}
 }
 
-// WithDoc returns an Option that filters to include only functions and structs with documentation.
+// WithDoc returns an Option that filters to include only functions, structs and methods with documentation.
 // This is useful to ensure that only documented code appears in the output.
+// Fields are always required to have either documentation or an inline comment.
 func WithDoc() Option {
 	return func(c *config) {
 		c.funcFilter = append(c.funcFilter, func(fn codoc.Function) bool {
@@ -63,6 +118,67 @@ func WithDoc() Option {
 		c.structFilter = append(c.structFilter, func(st codoc.Struct) bool {
 			return st.Doc != ""
 		})
+
+		c.methodFilter = append(c.methodFilter, func(m codoc.Function) bool {
+			return m.Doc != ""
+		})
+	}
+}
+
+// Tags returns an Option that sets the build tags used when selecting the package's source files.
+// Multiple calls accumulate tags.
+func Tags(tags ...string) Option {
+	return func(c *config) {
+		c.tags = append(c.tags, tags...)
+	}
+}
+
+// IncludeNames returns an Option that only includes items whose qualified ID matches one of the patterns.
+// IDs have the form "pkg.Func", "pkg.Type", "pkg.Type.Method" and "pkg.Type.Field", where pkg is
+// the package import path, or "main" for main packages.
+// A field or method is included if its own ID or that of its struct matches, and a struct
+// is included if its ID or the ID of any of its fields or methods matches.
+// Patterns are globs where '*' matches any sequence of characters, including dots and slashes,
+// unless they are enclosed in slashes, like "/^main\./", in which case they are regular expressions.
+func IncludeNames(patterns ...string) Option {
+	return func(c *config) {
+		c.include = append(c.include, c.compilePatterns(patterns)...)
+	}
+}
+
+// ExcludeNames returns an Option that leaves out items whose qualified ID matches one of the patterns.
+// It applies uniformly to functions, structs, methods and fields, using the same IDs and pattern
+// syntax as IncludeNames. Excluding a struct also excludes all of its fields and methods.
+func ExcludeNames(patterns ...string) Option {
+	return func(c *config) {
+		c.exclude = append(c.exclude, c.compilePatterns(patterns)...)
+	}
+}
+
+// SynopsisOnly returns an Option that keeps only the synopsis of every item, dropping the full
+// documentation and field comments. This reduces the size of generated documentation.
+// Filters such as WithDoc still see the full documentation.
+func SynopsisOnly() Option {
+	return func(c *config) {
+		c.synopsisOnly = true
+	}
+}
+
+// BestEffort returns an Option that extracts the documentation of whatever can be parsed from
+// packages with errors, like syntax errors or failed loads, instead of failing.
+// The problems found are appended to diags, which may be nil to ignore them.
+// Packages with no usable source files still fail.
+func BestEffort(diags *[]Diagnostic) Option {
+	return func(c *config) {
+		c.bestEffort = true
+		c.diags = diags
+	}
+}
+
+// report records diagnostics in best effort mode.
+func (c *config) report(diags ...Diagnostic) {
+	if c.diags != nil {
+		*c.diags = append(*c.diags, diags...)
 	}
 }
 
@@ -87,3 +203,130 @@ func (c *config) filterStruct(st codoc.Struct) bool {
 	}
 	return true
 }
+
+// filterField applies all field filters in the configuration to a struct field.
+// Returns true only if all filters return true, meaning the field should be included.
+func (c *config) filterField(f codoc.Field) bool {
+	for _, fn := range c.fieldFilter {
+		if !fn(f) {
+			return false
+		}
+	}
+	return true
+}
+
+// filterMethod applies all method filters in the configuration to a struct method,
+// along with the FilterFuncs filters if FilterMethods was not used.
+// Returns true only if all filters return true, meaning the method should be included.
+func (c *config) filterMethod(m codoc.Function) bool {
+	for _, f := range c.methodFilter {
+		if !f(m) {
+			return false
+		}
+	}
+	if c.userMethods {
+		return true
+	}
+	for _, f := range c.userFuncs {
+		if !f(m) {
+			return false
+		}
+	}
+	return true
+}
+
+// filterName reports whether a qualified ID matches the include patterns and none of the exclude patterns.
+func (c *config) filterName(id string) bool {
+	return c.includeName(id) && !c.excludeName(id)
+}
+
+// includeName reports whether a qualified ID matches the include patterns.
+// Returns true if there are no include patterns.
+func (c *config) includeName(id string) bool {
+	return len(c.include) == 0 || matchAny(c.include, id)
+}
+
+// excludeName reports whether a qualified ID matches any of the exclude patterns.
+func (c *config) excludeName(id string) bool {
+	return matchAny(c.exclude, id)
+}
+
+// compilePatterns compiles name patterns, recording the first invalid one in the config.
+func (c *config) compilePatterns(patterns []string) []*regexp.Regexp {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := compilePattern(p)
+		if err != nil {
+			if c.err == nil {
+				c.err = err
+			}
+			continue
+		}
+		res = append(res, re)
+	}
+	return res
+}
+
+// compilePattern compiles a glob pattern, or a regular expression if enclosed in slashes.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if len(pattern) >= 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %v", pattern, err)
+		}
+		return re, nil
+	}
+
+	// Translate the glob into an anchored regular expression
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch ch := pattern[i]; ch {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end == -1 {
+				return nil, fmt.Errorf("invalid pattern %q: unterminated character class", pattern)
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+			}
+			sb.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		default:
+			sb.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	sb.WriteString("$")
+
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %v", pattern, err)
+	}
+	return re, nil
+}
+
+// matchAny reports whether s matches any of the regular expressions.
+func matchAny(res []*regexp.Regexp, s string) bool {
+	for _, re := range res {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// isExported reports whether name starts with an uppercase letter.
+func isExported(name string) bool {
+	r, _ := utf8.DecodeRuneInString(name)
+	return unicode.IsUpper(r)
+}
@@ -37,6 +37,52 @@ func TestFilterStructs(t *testing.T) {
 	assert.False(t, c.filterStruct(codoc.Struct{Name: "RejectedStruct"}), "Struct 'RejectedStruct' should be rejected")
 }
 
+func TestFilterFields(t *testing.T) {
+	c := &config{}
+
+	// Add a filter that only accepts fields with an inline comment
+	FilterFields(func(f codoc.Field) bool {
+		return f.Comment != ""
+	})(c)
+
+	assert.True(t, c.filterField(codoc.Field{Name: "Commented", Comment: "comment"}), "Field 'Commented' should be accepted")
+	assert.False(t, c.filterField(codoc.Field{Name: "Uncommented", Doc: "doc"}), "Field 'Uncommented' should be rejected")
+}
+
+func TestFilterMethods(t *testing.T) {
+	c := &config{}
+
+	// Add a filter that only accepts methods named "AcceptedMethod"
+	FilterMethods(func(m codoc.Function) bool {
+		return m.Name == "AcceptedMethod"
+	})(c)
+
+	assert.True(t, c.filterMethod(codoc.Function{Name: "AcceptedMethod"}), "Method 'AcceptedMethod' should be accepted")
+	assert.False(t, c.filterMethod(codoc.Function{Name: "RejectedMethod"}), "Method 'RejectedMethod' should be rejected")
+
+	// Method filters should not affect functions
+	assert.True(t, c.filterFunc(codoc.Function{Name: "RejectedMethod"}), "Function 'RejectedMethod' should be accepted")
+}
+
+func TestFilterFuncsMethods(t *testing.T) {
+	c := &config{}
+	FilterFuncs(func(fn codoc.Function) bool {
+		return fn.Name != "Rejected"
+	})(c)
+	Exported()(c)
+
+	// Function filters apply to methods unless a method filter is set
+	assert.False(t, c.filterMethod(codoc.Function{Name: "Rejected"}), "Method 'Rejected' should be rejected by the function filter")
+	assert.True(t, c.filterMethod(codoc.Function{Name: "Accepted"}), "Method 'Accepted' should be accepted")
+
+	FilterMethods(func(m codoc.Function) bool {
+		return m.Name != "Accepted"
+	})(c)
+	assert.True(t, c.filterMethod(codoc.Function{Name: "Rejected"}), "Function filters should not apply once a method filter is set")
+	assert.False(t, c.filterMethod(codoc.Function{Name: "Accepted"}), "Method 'Accepted' should be rejected by the method filter")
+	assert.False(t, c.filterMethod(codoc.Function{Name: "unexported"}), "Exported should still apply to methods")
+}
+
 func TestExported(t *testing.T) {
 	c := &config{}
 
@@ -54,6 +100,14 @@ func TestExported(t *testing.T) {
 
 	// Test with unexported struct
 	assert.False(t, c.filterStruct(codoc.Struct{Name: "unexportedStruct"}), "Struct 'unexportedStruct' should be rejected")
+
+	// Test with exported and unexported fields
+	assert.True(t, c.filterField(codoc.Field{Name: "ExportedField"}), "Field 'ExportedField' should be accepted")
+	assert.False(t, c.filterField(codoc.Field{Name: "unexportedField"}), "Field 'unexportedField' should be rejected")
+
+	// Test with exported and unexported methods
+	assert.True(t, c.filterMethod(codoc.Function{Name: "ExportedMethod"}), "Method 'ExportedMethod' should be accepted")
+	assert.False(t, c.filterMethod(codoc.Function{Name: "unexportedMethod"}), "Method 'unexportedMethod' should be rejected")
 }
 
 func TestWithDoc(t *testing.T) {
@@ -108,3 +162,48 @@ func TestMultipleFilters(t *testing.T) {
 	assert.False(t, c.filterStruct(codoc.Struct{Name: "unexportedDocStruct", Doc: "This struct has docs"}),
 		"Unexported struct with documentation should be rejected")
 }
+
+func TestCompilePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		id      string
+		match   bool
+	}{
+		{"*.internal*", "example.com/pkg.internalHelper", true},
+		{"*.internal*", "example.com/internal/pkg.Func", false},
+		{"*Test*", "example.com/pkg.Type.TestField", true},
+		{"example.com/pkg.?unc", "example.com/pkg.Func", true},
+		{"example.com/pkg.[!F]unc", "example.com/pkg.Func", false},
+		{"example.com/pkg.Func", "example.com/pkgXFunc", false},
+		{"/^main\\./", "main.Foo", true},
+		{"/^main\\./", "example.com/main.Foo", false},
+	}
+
+	for _, tt := range tests {
+		re, err := compilePattern(tt.pattern)
+		if assert.NoError(t, err, "Pattern %q should compile", tt.pattern) {
+			assert.Equal(t, tt.match, re.MatchString(tt.id), "Pattern %q matching %q", tt.pattern, tt.id)
+		}
+	}
+
+	_, err := compilePattern("/[/")
+	assert.Error(t, err, "Invalid regexp should fail to compile")
+	_, err = compilePattern("[abc")
+	assert.Error(t, err, "Unterminated character class should fail to compile")
+}
+
+func TestIncludeExcludeNames(t *testing.T) {
+	c := &config{}
+
+	IncludeNames("example.com/pkg.*")(c)
+	ExcludeNames("*Test*", "/internal/")(c)
+	assert.NoError(t, c.err, "Valid patterns should not record an error")
+
+	assert.True(t, c.filterName("example.com/pkg.Func"), "Included name should be accepted")
+	assert.False(t, c.filterName("example.com/other.Func"), "Name not matching include should be rejected")
+	assert.False(t, c.filterName("example.com/pkg.TestFunc"), "Excluded name should be rejected")
+	assert.False(t, c.filterName("example.com/pkg.Type.internalField"), "Excluded name should be rejected")
+
+	ExcludeNames("/(/")(c)
+	assert.Error(t, c.err, "Invalid pattern should record an error")
+}
@@ -0,0 +1,124 @@
+package codocgen
+
+import (
+	"errors"
+	"go/scanner"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Diagnostic describes a problem found while extracting documentation in best effort mode.
+type Diagnostic struct {
+	Pos     token.Position // Position of the problem, with an empty file name if unknown
+	Message string         // Description of the problem
+}
+
+// String formats the diagnostic as "file:line:column: message", omitting unknown parts of the position.
+func (d Diagnostic) String() string {
+	if pos := d.Pos.String(); pos != "-" {
+		return pos + ": " + d.Message
+	}
+	return d.Message
+}
+
+// PackageError represents errors encountered during package loading and analysis.
+// It wraps a slice of packages.Error from the go/packages package.
+type PackageError []packages.Error
+
+// Error implements the error interface for PackageError, listing every underlying error.
+func (e PackageError) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return "package contains errors: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the underlying errors, for use with errors.Is and errors.As.
+func (e PackageError) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+// Diagnostics converts the underlying errors to diagnostics.
+func (e PackageError) Diagnostics() []Diagnostic {
+	diags := make([]Diagnostic, len(e))
+	for i, err := range e {
+		diags[i] = Diagnostic{Pos: parsePos(err.Pos), Message: err.Msg}
+	}
+	return diags
+}
+
+// ErrorDiagnostics extracts the problems reported by an error returned by codocgen, like a
+// PackageError or a syntax error, as diagnostics with positions.
+// Returns nil if the error carries no positions.
+func ErrorDiagnostics(err error) []Diagnostic {
+	var perr PackageError
+	if errors.As(err, &perr) {
+		return perr.Diagnostics()
+	}
+
+	var list scanner.ErrorList
+	var serr *scanner.Error
+	if errors.As(err, &list) || errors.As(err, &serr) {
+		return errorDiags(err)
+	}
+	return nil
+}
+
+// parsePos parses a position formatted like "file:line:column", as found in packages.Error.
+// The line and column are optional, and "-" or an empty string stand for an unknown position.
+func parsePos(s string) token.Position {
+	var pos token.Position
+	if s == "" || s == "-" {
+		return pos
+	}
+
+	// Up to two trailing numbers are the line and column, file names may contain colons
+	var nums []int
+	for len(nums) < 2 {
+		i := strings.LastIndexByte(s, ':')
+		if i < 0 {
+			break
+		}
+		n, err := strconv.Atoi(s[i+1:])
+		if err != nil {
+			break
+		}
+		nums = append([]int{n}, nums...)
+		s = s[:i]
+	}
+
+	pos.Filename = s
+	if len(nums) > 0 {
+		pos.Line = nums[0]
+	}
+	if len(nums) > 1 {
+		pos.Column = nums[1]
+	}
+	return pos
+}
+
+// errorDiags converts an error to diagnostics, with one per error of a scanner.ErrorList.
+func errorDiags(err error) []Diagnostic {
+	var list scanner.ErrorList
+	if errors.As(err, &list) {
+		diags := make([]Diagnostic, len(list))
+		for i, e := range list {
+			diags[i] = Diagnostic{Pos: e.Pos, Message: e.Msg}
+		}
+		return diags
+	}
+
+	var serr *scanner.Error
+	if errors.As(err, &serr) {
+		return []Diagnostic{{Pos: serr.Pos, Message: serr.Msg}}
+	}
+	return []Diagnostic{{Message: err.Error()}}
+}
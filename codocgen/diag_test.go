@@ -0,0 +1,62 @@
+package codocgen
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/tools/go/packages"
+)
+
+func TestParsePos(t *testing.T) {
+	tests := map[string]token.Position{
+		"":                 {},
+		"-":                {},
+		"a.go":             {Filename: "a.go"},
+		"a.go:3":           {Filename: "a.go", Line: 3},
+		"dir/a.go:3:14":    {Filename: "dir/a.go", Line: 3, Column: 14},
+		`C:\dir\a.go:3:14`: {Filename: `C:\dir\a.go`, Line: 3, Column: 14},
+	}
+	for in, expected := range tests {
+		assert.Equal(t, expected, parsePos(in), "Position %q should be parsed", in)
+	}
+}
+
+func TestPackageError(t *testing.T) {
+	perr := PackageError{
+		{Pos: "a.go:1:9", Msg: "expected 'package'", Kind: packages.ParseError},
+		{Msg: "no required module provides package", Kind: packages.ListError},
+	}
+
+	msg := perr.Error()
+	assert.Contains(t, msg, "a.go:1:9: expected 'package'", "Error should list the first error")
+	assert.Contains(t, msg, "no required module provides package", "Error should list the second error")
+
+	var err error = perr
+	var pe packages.Error
+	assert.True(t, errors.As(err, &pe), "Underlying errors should be unwrappable")
+
+	diags := perr.Diagnostics()
+	assert.Equal(t, []Diagnostic{
+		{Pos: token.Position{Filename: "a.go", Line: 1, Column: 9}, Message: "expected 'package'"},
+		{Message: "no required module provides package"},
+	}, diags)
+	assert.Equal(t, "a.go:1:9: expected 'package'", diags[0].String())
+	assert.Equal(t, "no required module provides package", diags[1].String())
+}
+
+func TestErrorDiagnostics(t *testing.T) {
+	_, err := FromFiles("example.com/broken", map[string]string{"a.go": "package broken\n\nfunc A() {\n"})
+	diags := ErrorDiagnostics(err)
+	if assert.Len(t, diags, 1, "Syntax errors should be extracted") {
+		assert.Equal(t, "a.go", diags[0].Pos.Filename)
+		assert.Equal(t, 3, diags[0].Pos.Line)
+	}
+
+	perr := fmt.Errorf("wrapped: %w", PackageError{{Pos: "a.go:1", Msg: "bad"}})
+	assert.Equal(t, []Diagnostic{{Pos: token.Position{Filename: "a.go", Line: 1}, Message: "bad"}}, ErrorDiagnostics(perr), "Package errors should be extracted")
+
+	assert.Nil(t, ErrorDiagnostics(errors.New("no position")), "Errors without positions should have no diagnostics")
+}
@@ -3,27 +3,46 @@
 package codocgen
 
 import (
+	"errors"
 	"fmt"
 	"go/ast"
+	"go/build"
+	"go/build/constraint"
 	"go/doc"
 	"go/parser"
 	"go/token"
+	"io"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/noonien/codoc"
 	"golang.org/x/tools/go/packages"
 )
 
+// GeneratedComment is the first line of the files generated by codoc.
+// Files holding it are skipped when extracting documentation, so that regenerating the docs
+// of a package that holds its own generated docs gives the same result.
+const GeneratedComment = "// Code generated by codoc. DO NOT EDIT."
+
 // RegisterPath registers a package at the given path with the codoc registry.
-// It analyzes the package, generates documentation, and adds it to the global registry.
+// It analyzes the package, generates documentation, and adds it to the default registry.
 // Options can be provided to filter what gets included in the documentation.
 func RegisterPath(path string, opts ...Option) error {
+	return RegisterPathTo(codoc.Default, path, opts...)
+}
+
+// RegisterPathTo registers a package at the given path with the given registry.
+// It works like RegisterPath, but allows targeting a registry other than the default one.
+func RegisterPathTo(r *codoc.Registry, path string, opts ...Option) error {
 	pkg, err := FromPath(path, opts...)
 	if err != nil {
 		return err
 	}
 
-	codoc.Register(*pkg)
+	r.Register(*pkg)
 	return nil
 }
 
@@ -32,37 +51,160 @@ func RegisterPath(path string, opts ...Option) error {
 // containing all the extracted documentation information.
 // Options can be provided to filter what gets included in the documentation.
 func FromPath(path string, opts ...Option) (*codoc.Package, error) {
-	conf := &config{}
-	for _, opt := range opts {
-		opt(conf)
+	conf, err := newConfig(opts)
+	if err != nil {
+		return nil, err
 	}
 
-	info, err := getInfo(path)
+	info, err := getInfo(path, conf)
 	if err != nil {
 		return nil, err
 	}
 
+	// Parse only the files that are part of the package for the current build context
 	fset := token.NewFileSet()
-	pkgs, err := parser.ParseDir(fset, path, nil, parser.ParseComments)
+	files := make([]*ast.File, 0, len(info.GoFiles))
+	for _, name := range info.GoFiles {
+		file, err := parseFile(conf, fset, name, nil)
+		if err != nil {
+			return nil, fmt.Errorf("parse package %q: %w", path, err)
+		}
+		if file != nil {
+			files = append(files, file)
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no go files in %q", path)
+	}
+
+	return newPackage(conf, fset, files, info.ID)
+}
+
+// FromFS generates documentation for the package whose source files are in the root
+// directory of fsys, like an embed.FS, under the given import path. Use fs.Sub for
+// packages in a subdirectory. It works like FromFiles.
+func FromFS(fsys fs.FS, importPath string, opts ...Option) (*codoc.Package, error) {
+	entries, err := fs.ReadDir(fsys, ".")
 	if err != nil {
-		return nil, fmt.Errorf("parse package %q: %v", path, err)
+		return nil, fmt.Errorf("read package %q: %v", importPath, err)
 	}
 
-	if len(pkgs) == 0 {
-		return nil, fmt.Errorf("no packages in %q", path)
+	srcs := make(map[string]string, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".go") || strings.HasSuffix(e.Name(), "_test.go") {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, fmt.Errorf("read package %q: %v", importPath, err)
+		}
+		srcs[e.Name()] = string(data)
 	}
-	if len(pkgs) > 1 {
-		return nil, fmt.Errorf("multiple packages in %q", path)
+
+	return FromFiles(importPath, srcs, opts...)
+}
+
+// FromFiles generates documentation for a package from its source files, given as a map
+// of file names to contents, like unsaved editor buffers, under the given import path.
+// Unlike FromPath, it only parses the files and never invokes the go command.
+// Test files are skipped, and the build constraints and GOOS and GOARCH file name suffixes
+// of the others are evaluated for the current platform and the build tags set with Tags.
+func FromFiles(importPath string, files map[string]string, opts ...Option) (*codoc.Package, error) {
+	conf, err := newConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Files are matched by base name, as they would be in a package directory
+	srcs := make(map[string]string, len(files))
+	for name, src := range files {
+		base := path.Base(filepath.ToSlash(name))
+		if _, ok := srcs[base]; ok {
+			return nil, fmt.Errorf("duplicate file %q in %q", base, importPath)
+		}
+		srcs[base] = src
+	}
+
+	ctx := build.Default
+	ctx.BuildTags = conf.tags
+	ctx.JoinPath = func(elem ...string) string { return elem[len(elem)-1] }
+	ctx.OpenFile = func(name string) (io.ReadCloser, error) {
+		src, ok := srcs[name]
+		if !ok {
+			return nil, fs.ErrNotExist
+		}
+		return io.NopCloser(strings.NewReader(src)), nil
 	}
 
-	pkgast := pkgs[info.Name]
-	pkgdoc := doc.New(pkgast, info.ID, doc.AllDecls)
+	names := make([]string, 0, len(srcs))
+	for name := range srcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fset := token.NewFileSet()
+	var parsed []*ast.File
+	for _, name := range names {
+		if !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		match, err := ctx.MatchFile("", name)
+		if err != nil && conf.bestEffort {
+			conf.report(Diagnostic{Pos: token.Position{Filename: name}, Message: err.Error()})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse package %q: %v", importPath, err)
+		}
+		if !match {
+			continue
+		}
+
+		file, err := parseFile(conf, fset, name, srcs[name])
+		if err != nil {
+			return nil, fmt.Errorf("parse package %q: %w", importPath, err)
+		}
+		if file == nil {
+			continue
+		}
+		if len(parsed) > 0 && file.Name.Name != parsed[0].Name.Name {
+			msg := fmt.Sprintf("multiple packages in %q: %s and %s", importPath, parsed[0].Name.Name, file.Name.Name)
+			if !conf.bestEffort {
+				return nil, errors.New(msg)
+			}
+			conf.report(Diagnostic{Pos: fset.Position(file.Name.Pos()), Message: msg})
+			continue
+		}
+		parsed = append(parsed, file)
+	}
+
+	if len(parsed) == 0 {
+		return nil, fmt.Errorf("no go files in %q", importPath)
+	}
+
+	return newPackage(conf, fset, parsed, importPath)
+}
+
+// newPackage extracts the documentation of a package from its parsed files, applying the config.
+func newPackage(conf *config, fset *token.FileSet, files []*ast.File, id string) (*codoc.Package, error) {
+	// Preserve the AST, file comments are extracted from it below
+	pkgdoc, err := doc.NewFromFiles(fset, files, id, doc.AllDecls|doc.PreserveAST)
+	if err != nil {
+		return nil, fmt.Errorf("read docs for %q: %v", id, err)
+	}
+
+	// Qualified IDs are prefixed the same way codoc.Register prefixes them
+	prefix := id + "."
+	if pkgdoc.Name == "main" {
+		prefix = "main."
+	}
 
 	// Extract all package functions
 	funcs := make(map[string]codoc.Function, len(pkgdoc.Funcs))
 	for _, fn := range pkgdoc.Funcs {
-		fn := getFunc(fn)
-		if conf.filterFunc(fn) {
+		fn := getFunc(pkgdoc, fn)
+		if conf.filterFunc(fn) && conf.filterName(prefix+fn.Name) {
 			funcs[fn.Name] = fn
 		}
 	}
@@ -78,17 +220,37 @@ func FromPath(path string, opts ...Option) (*codoc.Package, error) {
 
 		// Add functions associated with the type (but not methods)
 		for _, fn := range typ.Funcs {
-			fn := getFunc(fn)
-			if conf.filterFunc(fn) {
+			fn := getFunc(pkgdoc, fn)
+			if conf.filterFunc(fn) && conf.filterName(prefix+fn.Name) {
 				funcs[fn.Name] = fn
 			}
 		}
 
+		// Members are included by name if either they or their struct match,
+		// and a matching member pulls in its struct
+		stID := prefix + typ.Name
+		stMatched := conf.includeName(stID)
+		memberMatched := false
+		includeMember := func(name string) bool {
+			id := stID + "." + name
+			if conf.excludeName(id) {
+				return false
+			}
+			if stMatched {
+				return true
+			}
+			if conf.includeName(id) {
+				memberMatched = true
+				return true
+			}
+			return false
+		}
+
 		// Add methods of the struct
 		methods := make(map[string]codoc.Function, len(typ.Methods))
 		for _, fn := range typ.Methods {
-			m := getFunc(fn)
-			if conf.filterFunc(m) {
+			m := getFunc(pkgdoc, fn)
+			if conf.filterMethod(m) && includeMember(m.Name) {
 				methods[m.Name] = m
 			}
 		}
@@ -96,51 +258,120 @@ func FromPath(path string, opts ...Option) (*codoc.Package, error) {
 		// Extract field documentation
 		fields := map[string]codoc.Field{}
 		for _, field := range st.Fields.List {
+			doc := strings.TrimSpace(field.Doc.Text())
+			comment := strings.TrimSpace(field.Comment.Text())
+			if len(doc) == 0 && len(comment) == 0 {
+				continue
+			}
+			synopsis := pkgdoc.Synopsis(doc)
+			if len(synopsis) == 0 {
+				synopsis = pkgdoc.Synopsis(comment)
+			}
+
+			names := make([]string, 0, len(field.Names))
 			for _, name := range field.Names {
-				doc := strings.TrimSpace(field.Doc.Text())
-				comment := strings.TrimSpace(field.Comment.Text())
-				if len(doc) > 0 || len(comment) > 0 {
-					fields[name.Name] = codoc.Field{
-						Doc:     doc,
-						Comment: comment,
-					}
+				names = append(names, name.Name)
+			}
+			if len(field.Names) == 0 {
+				// Embedded fields are named after their type
+				names = append(names, embeddedName(field.Type))
+			}
+
+			for _, name := range names {
+				f := codoc.Field{
+					Name:     name,
+					Doc:      doc,
+					Comment:  comment,
+					Synopsis: synopsis,
+				}
+				if conf.filterField(f) && includeMember(name) {
+					fields[name] = f
 				}
 			}
 		}
 
 		cst := codoc.Struct{
-			Name:    typ.Name,
-			Doc:     strings.TrimSpace(typ.Doc),
-			Fields:  fields,
-			Methods: methods,
+			Name:     typ.Name,
+			Doc:      strings.TrimSpace(typ.Doc),
+			Synopsis: pkgdoc.Synopsis(typ.Doc),
+			Fields:   fields,
+			Methods:  methods,
 		}
 
-		if conf.filterStruct(cst) {
+		if conf.filterStruct(cst) && (stMatched || memberMatched) && !conf.excludeName(stID) {
 			structs[typ.Name] = cst
 		}
 	}
 
+	// Extract file level information
+	cfiles := make([]codoc.File, 0, len(files))
+	docFile := ""
+	for _, file := range files {
+		cf := getFile(fset, file)
+		cfiles = append(cfiles, cf)
+
+		// Prefer doc.go, as is conventional, if several files hold package docs
+		if file.Doc != nil && (docFile == "" || cf.Name == "doc.go") {
+			docFile = cf.Name
+		}
+	}
+	sort.Slice(cfiles, func(i, j int) bool { return cfiles[i].Name < cfiles[j].Name })
+
 	// Create the complete package documentation
-	return &codoc.Package{
-		Name:      info.Name,
-		ID:        info.ID,
+	pkg := &codoc.Package{
+		Name:      pkgdoc.Name,
+		ID:        id,
 		Doc:       strings.TrimSpace(pkgdoc.Doc),
+		Synopsis:  pkgdoc.Synopsis(pkgdoc.Doc),
+		DocFile:   docFile,
+		Files:     cfiles,
 		Functions: funcs,
 		Structs:   structs,
-	}, nil
-}
+	}
+	if conf.synopsisOnly {
+		dropDocs(pkg)
+	}
 
-// PackageError represents errors encountered during package loading and analysis.
-// It wraps a slice of packages.Error from the go/packages package.
-type PackageError []packages.Error
+	return pkg, nil
+}
 
-// Error implements the error interface for PackageError.
-func (PackageError) Error() string { return "package contains errors" }
+// dropDocs clears the full documentation of a package and everything in it, keeping only synopses.
+// Filters have already been applied, so they still see the full documentation.
+func dropDocs(pkg *codoc.Package) {
+	pkg.Doc = ""
+	for i := range pkg.Files {
+		pkg.Files[i].Doc = ""
+	}
+	for name, fn := range pkg.Functions {
+		fn.Doc = ""
+		pkg.Functions[name] = fn
+	}
+	for name, st := range pkg.Structs {
+		st.Doc = ""
+		for fname, f := range st.Fields {
+			f.Doc = ""
+			f.Comment = ""
+			st.Fields[fname] = f
+		}
+		for mname, m := range st.Methods {
+			m.Doc = ""
+			st.Methods[mname] = m
+		}
+		pkg.Structs[name] = st
+	}
+}
 
 // getInfo loads basic package information using the go/packages API.
 // It returns a *packages.Package with the loaded package information.
-func getInfo(path string) (*packages.Package, error) {
-	infos, err := packages.Load(nil, path)
+func getInfo(path string, conf *config) (*packages.Package, error) {
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles,
+	}
+	if len(conf.tags) > 0 {
+		cfg.BuildFlags = []string{"-tags", strings.Join(conf.tags, ",")}
+	}
+
+	infos, err := packages.Load(cfg, path)
 	if err != nil {
 		return nil, fmt.Errorf("load package %q: %v", path, err)
 	}
@@ -154,16 +385,57 @@ func getInfo(path string) (*packages.Package, error) {
 
 	info := infos[0]
 	if len(info.Errors) > 0 {
-		return nil, PackageError(info.Errors)
+		if !conf.bestEffort || len(info.GoFiles) == 0 {
+			return nil, PackageError(info.Errors)
+		}
+		conf.report(PackageError(info.Errors).Diagnostics()...)
 	}
 
 	return info, nil
 }
 
+// parseFile parses a source file, read from name if src is nil.
+// Returns nil for files generated by codoc. In best effort mode, syntax errors are reported
+// and the partial file is returned, or nil if not even its package clause could be parsed.
+func parseFile(conf *config, fset *token.FileSet, name string, src any) (*ast.File, error) {
+	file, err := parser.ParseFile(fset, name, src, parser.ParseComments)
+	if file != nil && isGenerated(file) {
+		return nil, nil
+	}
+	if err == nil {
+		return file, nil
+	}
+	if !conf.bestEffort || file == nil {
+		return nil, err
+	}
+
+	conf.report(errorDiags(err)...)
+	if file.Name == nil || file.Name.Name == "" || file.Name.Name == "_" {
+		return nil, nil
+	}
+	return file, nil
+}
+
+// isGenerated reports whether a file was generated by codoc, with GeneratedComment
+// preceding its package clause.
+func isGenerated(file *ast.File) bool {
+	for _, cg := range file.Comments {
+		if cg.Pos() >= file.Package {
+			break
+		}
+		for _, c := range cg.List {
+			if c.Text == GeneratedComment {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // getFunc extracts function information from a *doc.Func.
 // It extracts the function name, documentation, arguments, and results,
 // and returns a codoc.Function.
-func getFunc(fn *doc.Func) codoc.Function {
+func getFunc(pkgdoc *doc.Package, fn *doc.Func) codoc.Function {
 	dt := fn.Decl.Type
 
 	// Extract argument names
@@ -191,9 +463,68 @@ func getFunc(fn *doc.Func) codoc.Function {
 	}
 
 	return codoc.Function{
-		Name:    fn.Name,
-		Doc:     strings.TrimSpace(fn.Doc),
-		Args:    args,
-		Results: results,
+		Name:     fn.Name,
+		Doc:      strings.TrimSpace(fn.Doc),
+		Synopsis: pkgdoc.Synopsis(fn.Doc),
+		Args:     args,
+		Results:  results,
+	}
+}
+
+// getFile extracts file level information from a parsed file.
+// Leading comments are those preceding the package clause, except for the package
+// documentation and build constraints.
+func getFile(fset *token.FileSet, file *ast.File) codoc.File {
+	cf := codoc.File{
+		Name: filepath.Base(fset.Position(file.Package).Filename),
+	}
+
+	var docs []string
+	for _, cg := range file.Comments {
+		if cg.Pos() >= file.Package {
+			break
+		}
+
+		// Look for build constraints, preferring //go:build over legacy // +build lines
+		isConstraint := false
+		for _, c := range cg.List {
+			if !constraint.IsGoBuild(c.Text) && !constraint.IsPlusBuild(c.Text) {
+				continue
+			}
+			isConstraint = true
+
+			expr, err := constraint.Parse(c.Text)
+			if err == nil && (cf.Constraint == "" || constraint.IsGoBuild(c.Text)) {
+				cf.Constraint = expr.String()
+			}
+		}
+
+		if cg == file.Doc || isConstraint {
+			continue
+		}
+		if text := strings.TrimSpace(cg.Text()); len(text) > 0 {
+			docs = append(docs, text)
+		}
+	}
+	cf.Doc = strings.Join(docs, "\n\n")
+
+	return cf
+}
+
+// embeddedName returns the field name of an embedded field with the given type expression.
+// The name is that of the type, stripped of pointers, package qualifiers and type arguments.
+func embeddedName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return embeddedName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	case *ast.IndexExpr:
+		return embeddedName(t.X)
+	case *ast.IndexListExpr:
+		return embeddedName(t.X)
 	}
+	return ""
 }
@@ -20,9 +20,7 @@ func TestRegisterPathWithNonExistentPath(t *testing.T) {
 // TestPathOptionsExported tests the Exported option with FromPath
 func TestPathOptionsExported(t *testing.T) {
 	// Use the existing test package instead of creating a temp directory
-	pwd, err := os.Getwd()
-	require.NoError(t, err, "Failed to get current directory")
-	testpkgPath := filepath.Join(pwd, "testpkg")
+	testpkgPath := testpkgDir(t)
 
 	// Test FromPath with Exported option
 	pkg, err := FromPath(testpkgPath, Exported())
@@ -37,11 +35,44 @@ func TestPathOptionsExported(t *testing.T) {
 		assert.NotRegexp(t, "^[a-z]", fnName, "Unexported function %s was included despite Exported() option", fnName)
 	}
 
-	for stName := range pkg.Structs {
+	for stName, st := range pkg.Structs {
 		assert.NotRegexp(t, "^[a-z]", stName, "Unexported struct %s was included despite Exported() option", stName)
+
+		for fieldName := range st.Fields {
+			assert.NotRegexp(t, "^[a-z]", fieldName, "Unexported field %s.%s was included despite Exported() option", stName, fieldName)
+		}
+		for methodName := range st.Methods {
+			assert.NotRegexp(t, "^[a-z]", methodName, "Unexported method %s.%s was included despite Exported() option", stName, methodName)
+		}
 	}
 }
 
+// TestPathFields tests that named and embedded fields are extracted with FromPath
+func TestPathFields(t *testing.T) {
+	testpkgPath := testpkgDir(t)
+
+	pkg, err := FromPath(testpkgPath)
+	require.NoError(t, err, "FromPath failed")
+
+	st, ok := pkg.Structs["ExportedType"]
+	require.True(t, ok, "Struct 'ExportedType' not found")
+
+	for _, name := range []string{"ExportedField", "unexportedField", "unexportedType"} {
+		field, ok := st.Fields[name]
+		if assert.True(t, ok, "Field %s not found", name) {
+			assert.Equal(t, name, field.Name, "Field name mismatch")
+		}
+	}
+	assert.Contains(t, st.Methods, "unexportedMethod", "Method 'unexportedMethod' not found")
+}
+
+// testpkgDir returns the absolute path of the test package.
+func testpkgDir(t *testing.T) string {
+	pwd, err := os.Getwd()
+	require.NoError(t, err, "Failed to get current directory")
+	return filepath.Join(pwd, "testpkg")
+}
+
 // TestConcurrentRegisterAndGet tests concurrent access to Register and Get functions
 func TestConcurrentRegisterAndGet(t *testing.T) {
 	var wg sync.WaitGroup
@@ -96,3 +127,232 @@ func TestConcurrentRegisterAndGet(t *testing.T) {
 	// Wait for both goroutines to complete
 	wg.Wait()
 }
+
+// TestPathTags tests that build tags select the files documented by FromPath
+func TestPathTags(t *testing.T) {
+	testpkgPath := testpkgDir(t)
+
+	pkg, err := FromPath(testpkgPath)
+	require.NoError(t, err, "FromPath failed")
+	assert.NotContains(t, pkg.Functions, "TaggedFunc", "Tagged function included without build tag")
+
+	pkg, err = FromPath(testpkgPath, Tags("codoctest"))
+	require.NoError(t, err, "FromPath with tags failed")
+	assert.Contains(t, pkg.Functions, "TaggedFunc", "Tagged function missing with build tag")
+}
+
+// TestPathNames tests the IncludeNames and ExcludeNames options with FromPath
+func TestPathNames(t *testing.T) {
+	testpkgPath := testpkgDir(t)
+
+	// Including a method pulls in its struct, but not its other members
+	pkg, err := FromPath(testpkgPath, IncludeNames("*.ExportedType.ExportedMethod"))
+	require.NoError(t, err, "FromPath failed")
+	assert.Empty(t, pkg.Functions, "No functions should be included")
+	require.Contains(t, pkg.Structs, "ExportedType", "Struct of included method should be included")
+	st := pkg.Structs["ExportedType"]
+	assert.Contains(t, st.Methods, "ExportedMethod", "Included method should be present")
+	assert.NotContains(t, st.Methods, "unexportedMethod", "Other methods should not be included")
+	assert.Empty(t, st.Fields, "Fields should not be included")
+
+	// Excluding applies to every kind of item
+	pkg, err = FromPath(testpkgPath, ExcludeNames("*.unexported*"))
+	require.NoError(t, err, "FromPath with exclude patterns failed")
+	assert.NotContains(t, pkg.Functions, "unexportedFunc", "Excluded function should be left out")
+	assert.NotContains(t, pkg.Structs["ExportedType"].Fields, "unexportedField", "Excluded field should be left out")
+	assert.NotContains(t, pkg.Structs["ExportedType"].Methods, "unexportedMethod", "Excluded method should be left out")
+	assert.Contains(t, pkg.Structs["ExportedType"].Fields, "ExportedField", "Other fields should be kept")
+
+	_, err = FromPath(testpkgPath, IncludeNames("/(/"))
+	assert.Error(t, err, "Invalid pattern should fail FromPath")
+}
+
+// TestPathSynopsis tests that synopses are extracted, and that SynopsisOnly drops full docs
+func TestPathSynopsis(t *testing.T) {
+	testpkgPath := testpkgDir(t)
+
+	pkg, err := FromPath(testpkgPath)
+	require.NoError(t, err, "FromPath failed")
+	st := pkg.Structs["ExportedType"]
+	assert.Equal(t, "ExportedType is an exported struct.", st.Synopsis, "Struct synopsis mismatch")
+	assert.Contains(t, st.Doc, "some of which are unexported", "Struct doc should be complete")
+	assert.Equal(t, "ExportedField is an exported field", st.Fields["ExportedField"].Synopsis,
+		"Field synopsis should fall back to the comment")
+	assert.Equal(t, "ExportedFunc is an exported function", pkg.Functions["ExportedFunc"].Synopsis,
+		"Function synopsis mismatch")
+
+	pkg, err = FromPath(testpkgPath, SynopsisOnly(), WithDoc())
+	require.NoError(t, err, "FromPath with SynopsisOnly failed")
+	st = pkg.Structs["ExportedType"]
+	assert.Equal(t, "ExportedType is an exported struct.", st.Synopsis, "Struct synopsis mismatch")
+	assert.Empty(t, st.Doc, "Struct doc should be dropped")
+	assert.Empty(t, st.Fields["ExportedField"].Comment, "Field comment should be dropped")
+	assert.Empty(t, pkg.Functions["ExportedFunc"].Doc, "Function doc should be dropped")
+	assert.Contains(t, pkg.Functions, "ExportedFunc", "WithDoc should see the full documentation")
+}
+
+// TestPathFiles tests that file level information is extracted with FromPath
+func TestPathFiles(t *testing.T) {
+	testpkgPath := testpkgDir(t)
+
+	pkg, err := FromPath(testpkgPath, Tags("codoctest"))
+	require.NoError(t, err, "FromPath failed")
+
+	assert.Equal(t, "Package testpkg is used to test documentation extraction.", pkg.Doc, "Package doc mismatch")
+	assert.Equal(t, "doc.go", pkg.DocFile, "Package doc file mismatch")
+	assert.Equal(t, []codoc.File{
+		{Name: "doc.go", Doc: "This file holds the package documentation."},
+		{Name: "pkg.go"},
+		{Name: "tagged.go", Constraint: "codoctest"},
+	}, pkg.Files, "Package files mismatch")
+}
+
+// TestRegisterPathTo tests registering a package with a specific registry
+func TestRegisterPathTo(t *testing.T) {
+	testpkgPath := testpkgDir(t)
+
+	r := codoc.NewRegistry()
+	require.NoError(t, RegisterPathTo(r, testpkgPath), "RegisterPathTo failed")
+
+	const id = "github.com/noonien/codoc/codocgen/testpkg"
+	assert.NotNil(t, r.GetPackage(id), "Package should be in the given registry")
+	assert.NotNil(t, r.GetFunction(id+".ExportedFunc"), "Function should be in the given registry")
+	assert.Nil(t, codoc.GetPackage(id), "Package should not be in the default registry")
+}
+
+// TestFromFS tests that FromFS extracts the same docs as FromPath without the go command
+func TestFromFS(t *testing.T) {
+	const importPath = "github.com/noonien/codoc/codocgen/testpkg"
+
+	pkg, err := FromFS(os.DirFS("testpkg"), importPath)
+	require.NoError(t, err, "FromFS failed")
+	assert.Equal(t, importPath, pkg.ID)
+	assert.Equal(t, "testpkg", pkg.Name)
+	assert.Equal(t, "doc.go", pkg.DocFile)
+	assert.NotContains(t, pkg.Functions, "TaggedFunc", "Tagged function included without build tag")
+
+	pkg, err = FromFS(os.DirFS("testpkg"), importPath, Tags("codoctest"))
+	require.NoError(t, err, "FromFS with tags failed")
+	assert.Contains(t, pkg.Functions, "TaggedFunc", "Tagged function missing with build tag")
+
+	expected, err := FromPath("./testpkg", Tags("codoctest"))
+	require.NoError(t, err, "FromPath failed")
+	assert.Equal(t, expected, pkg, "FromFS and FromPath should extract the same docs")
+}
+
+// TestFromFiles tests extracting docs from in-memory sources
+func TestFromFiles(t *testing.T) {
+	files := map[string]string{
+		"a.go":          "// Package mem is in memory.\npackage mem\n\n// A does a.\nfunc A() {}\n",
+		"dir/b.go":      "package mem\n\n// B does b.\nfunc B(x int) (y int) { return x }\n",
+		"c_test.go":     "package mem\n\nfunc C() {}\n",
+		"d_plan9.go":    "package mem\n\nfunc D() {}\n",
+		"e.go":          "//go:build ignore\n\npackage main\n\nfunc E() {}\n",
+		"README.md":     "not go",
+		"f_codocgen.go": "//go:build mem\n\npackage mem\n\nfunc F() {}\n",
+	}
+
+	pkg, err := FromFiles("example.com/mem", files)
+	require.NoError(t, err, "FromFiles failed")
+	assert.Equal(t, "mem", pkg.Name)
+	assert.Equal(t, "Package mem is in memory.", pkg.Synopsis)
+	assert.ElementsMatch(t, []string{"A", "B"}, keys(pkg.Functions), "Only files matching the build context should be parsed")
+	assert.Equal(t, []string{"x"}, pkg.Functions["B"].Args)
+
+	pkg, err = FromFiles("example.com/mem", files, Tags("mem"))
+	require.NoError(t, err)
+	assert.Contains(t, pkg.Functions, "F", "Build tags should select files")
+
+	_, err = FromFiles("example.com/mem", map[string]string{"a.go": "package a", "b.go": "package b"})
+	assert.ErrorContains(t, err, "multiple packages", "Files of different packages should fail")
+
+	_, err = FromFiles("example.com/mem", map[string]string{"a.go": "package a", "x/a.go": "package a"})
+	assert.ErrorContains(t, err, "duplicate file", "Files with the same name should fail")
+
+	_, err = FromFiles("example.com/mem", map[string]string{"a.go": "package"})
+	assert.Error(t, err, "Syntax errors should fail")
+
+	_, err = FromFiles("example.com/mem", map[string]string{"a_test.go": "package a"})
+	assert.ErrorContains(t, err, "no go files", "Packages with only test files should fail")
+}
+
+// TestFromFilesGenerated tests that files generated by codoc are skipped, unlike other generated files
+func TestFromFilesGenerated(t *testing.T) {
+	files := map[string]string{
+		"a.go":         "// Package gen has generated docs.\npackage gen\n\n// A does a.\nfunc A() {}\n",
+		"zz_codoc.go":  GeneratedComment + "\n// codoc version: (devel)\n\npackage gen\n\nfunc init() {}\n",
+		"a_string.go":  "// Code generated by \"stringer\"; DO NOT EDIT.\n\npackage gen\n\n// String returns the name.\nfunc String() string { return \"\" }\n",
+		"not_first.go": "// Some comment.\n\n" + GeneratedComment + "\n\npackage gen\n",
+	}
+
+	pkg, err := FromFiles("example.com/gen", files)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"A", "String"}, keys(pkg.Functions), "Files generated by codoc should be skipped")
+	for _, f := range pkg.Files {
+		assert.NotEqual(t, "zz_codoc.go", f.Name, "Files generated by codoc should not be listed")
+		assert.NotEqual(t, "not_first.go", f.Name, "The generated comment may follow other comments")
+	}
+
+	_, err = FromFiles("example.com/gen", map[string]string{"zz_codoc.go": files["zz_codoc.go"]})
+	assert.ErrorContains(t, err, "no go files", "Packages with only generated docs have nothing to document")
+}
+
+func keys[V any](m map[string]V) []string {
+	var ks []string
+	for k := range m {
+		ks = append(ks, k)
+	}
+	return ks
+}
+
+// TestBestEffort tests that docs are extracted from packages with syntax errors
+func TestBestEffort(t *testing.T) {
+	files := map[string]string{
+		"a.go":   "package broken\n\n// A does a.\nfunc A() {}\n\n// B is broken.\nfunc B() {\n",
+		"b.go":   "package broken\n\n// T is a type.\ntype T struct {\n\tX int // X is x\n}\n",
+		"c.go":   "packag broken\n",
+		"d.go":   "package other\n",
+		"bad.go": "//go:build (\n\npackage broken\n",
+	}
+
+	_, err := FromFiles("example.com/broken", files)
+	assert.Error(t, err, "Syntax errors should fail without BestEffort")
+
+	var diags []Diagnostic
+	pkg, err := FromFiles("example.com/broken", files, BestEffort(&diags))
+	require.NoError(t, err, "BestEffort should not fail")
+	assert.Contains(t, pkg.Functions, "A", "Functions before the error should be extracted")
+	assert.Contains(t, pkg.Structs, "T", "Other files should be extracted")
+	assert.Equal(t, "X is x", pkg.Structs["T"].Fields["X"].Comment)
+
+	reported := map[string]bool{}
+	for _, d := range diags {
+		assert.NotEmpty(t, d.Message)
+		reported[d.Pos.Filename] = true
+	}
+	assert.Equal(t, map[string]bool{"a.go": true, "c.go": true, "d.go": true, "bad.go": true}, reported, "Every problem should be reported with its file")
+
+	_, err = FromFiles("example.com/broken", map[string]string{"c.go": "packag broken\n"}, BestEffort(nil))
+	assert.ErrorContains(t, err, "no go files", "Packages with nothing usable should still fail")
+}
+
+// TestPathBestEffort tests best effort extraction through the go command
+func TestPathBestEffort(t *testing.T) {
+	// The package must be in the main module, directories starting with _ are ignored by ./...
+	dir, err := os.MkdirTemp(".", "_broken")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.go"), []byte("package broken\n\nimport \"example.com/missing\"\n\n// A does a.\nfunc A() { missing.X() }\n\nfunc B() {\n"), 0o644))
+	dir = "./" + dir
+
+	_, err = FromPath(dir)
+	if err == nil {
+		t.Skip("Skipping test since the broken package loaded without errors")
+	}
+
+	var diags []Diagnostic
+	pkg, err := FromPath(dir, BestEffort(&diags))
+	require.NoError(t, err, "BestEffort should not fail")
+	assert.Contains(t, pkg.Functions, "A", "Parseable functions should be extracted")
+	assert.NotEmpty(t, diags, "Problems should be reported")
+}
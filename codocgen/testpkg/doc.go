@@ -0,0 +1,4 @@
+// This file holds the package documentation.
+
+// Package testpkg is used to test documentation extraction.
+package testpkg
@@ -6,8 +6,23 @@ func ExportedFunc() {}
 // unexportedFunc is an unexported function
 func unexportedFunc() {}
 
-// ExportedType is an exported struct
-type ExportedType struct{}
+// ExportedType is an exported struct. It has fields and methods,
+// some of which are unexported.
+type ExportedType struct {
+	// unexportedType is an embedded unexported struct
+	unexportedType
+
+	ExportedField int // ExportedField is an exported field
+
+	// unexportedField is an unexported field
+	unexportedField int
+}
+
+// ExportedMethod is an exported method
+func (ExportedType) ExportedMethod() {}
+
+// unexportedMethod is an unexported method
+func (ExportedType) unexportedMethod() {}
 
 // unexportedType is an unexported struct
 type unexportedType struct{}
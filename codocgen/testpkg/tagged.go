@@ -0,0 +1,6 @@
+//go:build codoctest
+
+package testpkg
+
+// TaggedFunc is only built with the codoctest tag
+func TaggedFunc() {}
@@ -0,0 +1,120 @@
+package codoc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DocumentVersion is the version of the Document schema written by this package.
+// It is increased whenever the encoding changes in a way older readers cannot handle.
+const DocumentVersion = 1
+
+// Document is the serialized form of a set of packages, as written to JSON and YAML.
+type Document struct {
+	Version  int       `json:"version" yaml:"version"`   // Schema version, see DocumentVersion
+	Packages []Package `json:"packages" yaml:"packages"` // Documented packages, sorted by ID
+}
+
+// NewDocument creates a Document of the current version holding the packages, sorted by ID.
+func NewDocument(pkgs ...Package) Document {
+	sorted := append([]Package(nil), pkgs...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	return Document{Version: DocumentVersion, Packages: sorted}
+}
+
+// EncodeJSON writes the document as indented JSON.
+// Map keys are sorted, so the output only depends on the contents of the document.
+func EncodeJSON(w io.Writer, doc Document) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(doc)
+}
+
+// DecodeJSON reads a JSON document written by EncodeJSON.
+// Returns an error if the document has no version, or a version newer than DocumentVersion.
+func DecodeJSON(r io.Reader) (*Document, error) {
+	doc := &Document{}
+	if err := json.NewDecoder(r).Decode(doc); err != nil {
+		return nil, fmt.Errorf("codoc: decode JSON document: %v", err)
+	}
+	if err := doc.check(); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// EncodeYAML writes the document as YAML.
+// Map keys are sorted, so the output only depends on the contents of the document.
+func EncodeYAML(w io.Writer, doc Document) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// DecodeYAML reads a YAML document written by EncodeYAML.
+// Returns an error if the document has no version, or a version newer than DocumentVersion.
+func DecodeYAML(r io.Reader) (*Document, error) {
+	doc := &Document{}
+	if err := yaml.NewDecoder(r).Decode(doc); err != nil {
+		return nil, fmt.Errorf("codoc: decode YAML document: %v", err)
+	}
+	if err := doc.check(); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// check validates the version of a decoded document.
+func (d *Document) check() error {
+	if d.Version == 0 {
+		return fmt.Errorf("codoc: document has no version")
+	}
+	if d.Version > DocumentVersion {
+		return fmt.Errorf("codoc: unsupported document version %d, expected at most %d", d.Version, DocumentVersion)
+	}
+	return nil
+}
+
+// LoadJSON reads a JSON document and registers its packages with the default registry.
+func LoadJSON(r io.Reader) error { return Default.LoadJSON(r) }
+
+// LoadYAML reads a YAML document and registers its packages with the default registry.
+func LoadYAML(r io.Reader) error { return Default.LoadYAML(r) }
+
+// LoadJSON reads a JSON document and registers its packages with the registry,
+// replacing packages already registered under the same IDs.
+// Nothing is registered if the document cannot be read.
+func (r *Registry) LoadJSON(rd io.Reader) error {
+	doc, err := DecodeJSON(rd)
+	if err != nil {
+		return err
+	}
+	r.RegisterDocument(*doc)
+	return nil
+}
+
+// LoadYAML reads a YAML document and registers its packages with the registry,
+// replacing packages already registered under the same IDs.
+// Nothing is registered if the document cannot be read.
+func (r *Registry) LoadYAML(rd io.Reader) error {
+	doc, err := DecodeYAML(rd)
+	if err != nil {
+		return err
+	}
+	r.RegisterDocument(*doc)
+	return nil
+}
+
+// RegisterDocument registers every package of a document with the registry.
+func (r *Registry) RegisterDocument(doc Document) {
+	for _, pkg := range doc.Packages {
+		r.Register(pkg)
+	}
+}
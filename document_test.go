@@ -0,0 +1,93 @@
+package codoc
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocumentRoundTrip(t *testing.T) {
+	doc := NewDocument(encodeTestPackage(), Package{ID: "example.com/a", Name: "a"})
+	require.Len(t, doc.Packages, 2)
+	assert.Equal(t, "example.com/a", doc.Packages[0].ID, "Packages should be sorted by ID")
+	assert.Equal(t, DocumentVersion, doc.Version)
+
+	var buf bytes.Buffer
+	require.NoError(t, EncodeJSON(&buf, doc))
+	assert.Contains(t, buf.String(), `"doc_file": "doc.go"`, "JSON should use the schema names")
+	assert.NotContains(t, buf.String(), `"fields": {}`, "Empty values should be omitted")
+
+	decoded, err := DecodeJSON(&buf)
+	require.NoError(t, err, "JSON should decode")
+	assert.Equal(t, "Codec is a codec.", decoded.Packages[1].Structs["Codec"].Doc)
+	assert.Equal(t, "Compression level", decoded.Packages[1].Structs["Codec"].Fields["Level"].Comment)
+
+	buf.Reset()
+	require.NoError(t, EncodeYAML(&buf, doc))
+	assert.Contains(t, buf.String(), "doc_file: doc.go", "YAML should use the schema names")
+
+	decodedYAML, err := DecodeYAML(&buf)
+	require.NoError(t, err, "YAML should decode")
+	assert.Equal(t, decoded, decodedYAML, "JSON and YAML should decode to the same document")
+}
+
+func TestDocumentDeterministic(t *testing.T) {
+	doc := NewDocument(encodeTestPackage())
+
+	var a, b bytes.Buffer
+	require.NoError(t, EncodeJSON(&a, doc))
+	require.NoError(t, EncodeJSON(&b, doc))
+	assert.Equal(t, a.String(), b.String(), "JSON output should be deterministic")
+
+	a.Reset()
+	b.Reset()
+	require.NoError(t, EncodeYAML(&a, doc))
+	require.NoError(t, EncodeYAML(&b, doc))
+	assert.Equal(t, a.String(), b.String(), "YAML output should be deterministic")
+}
+
+func TestDocumentVersion(t *testing.T) {
+	_, err := DecodeJSON(strings.NewReader(`{"packages": []}`))
+	assert.ErrorContains(t, err, "no version", "Documents must have a version")
+
+	_, err = DecodeYAML(strings.NewReader("version: 99\npackages: []\n"))
+	assert.ErrorContains(t, err, "version 99", "Newer versions should be rejected")
+
+	_, err = DecodeJSON(strings.NewReader(`{"version": 1, "packages": [`))
+	assert.Error(t, err, "Malformed documents should be rejected")
+}
+
+func TestLoad(t *testing.T) {
+	r := NewRegistry()
+	err := r.LoadJSON(strings.NewReader(`{
+		"version": 1,
+		"packages": [{
+			"id": "example.com/loaded",
+			"name": "loaded",
+			"functions": {"Run": {"name": "Run", "doc": "Run runs."}}
+		}]
+	}`))
+	require.NoError(t, err)
+	fn := r.GetFunction("example.com/loaded.Run")
+	require.NotNil(t, fn, "Loaded functions should be registered")
+	assert.Equal(t, "Run runs.", fn.Doc)
+
+	err = r.LoadYAML(strings.NewReader(`
+version: 1
+packages:
+  - id: example.com/loaded
+    name: loaded
+    structs:
+      T: {name: T, doc: T is a type.}
+`))
+	require.NoError(t, err)
+	assert.Nil(t, r.GetFunction("example.com/loaded.Run"), "Loading should replace registered packages")
+	assert.NotNil(t, r.GetStruct("example.com/loaded.T"))
+
+	err = r.LoadYAML(strings.NewReader("packages: [{id: example.com/other, name: other}]"))
+	assert.Error(t, err)
+	assert.Nil(t, r.GetPackage("example.com/other"), "Nothing should be registered on error")
+}
@@ -0,0 +1,320 @@
+package codoc
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Binary encoding header.
+// Encoded packages start with the magic bytes, followed by the format version and flags.
+const (
+	binaryMagic    = "codoc"
+	binaryVersion  = 1
+	flagCompressed = 1 << 0 // Payload is compressed with DEFLATE
+)
+
+// binaryHeaderLen is the length of the header of encoded packages.
+const binaryHeaderLen = len(binaryMagic) + 2
+
+// errCorrupt is returned when decoding malformed binary data.
+var errCorrupt = errors.New("codoc: corrupt binary data")
+
+// EncodeBinary encodes a package in a compact binary format, optionally compressed.
+// Map entries are written in sorted order, so the output only depends on the contents
+// of the package. Use DecodeBinary or RegisterLazy to read it back.
+func EncodeBinary(pkg Package, compress bool) ([]byte, error) {
+	var payload binaryWriter
+	payload.pkg(pkg)
+
+	var buf bytes.Buffer
+	buf.WriteString(binaryMagic)
+	if !compress {
+		buf.Write([]byte{binaryVersion, 0})
+		buf.Write(payload.Bytes())
+		return buf.Bytes(), nil
+	}
+
+	buf.Write([]byte{binaryVersion, flagCompressed})
+	fw, err := flate.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fw.Write(payload.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := fw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodeBinary decodes a package encoded with EncodeBinary.
+func DecodeBinary(data []byte) (Package, error) {
+	flags, err := readHeader(data)
+	if err != nil {
+		return Package{}, err
+	}
+
+	payload := data[binaryHeaderLen:]
+	if flags&flagCompressed != 0 {
+		var err error
+		payload, err = io.ReadAll(flate.NewReader(bytes.NewReader(payload)))
+		if err != nil {
+			return Package{}, fmt.Errorf("codoc: decompress binary data: %v", err)
+		}
+	}
+
+	br := &binaryReader{data: payload}
+	pkg := br.pkg()
+	if br.err != nil {
+		return Package{}, br.err
+	}
+	if len(br.data) > 0 {
+		return Package{}, errCorrupt
+	}
+	return pkg, nil
+}
+
+// readHeader validates the header of an encoded package, returning its flags.
+func readHeader(data []byte) (byte, error) {
+	if len(data) < binaryHeaderLen || string(data[:len(binaryMagic)]) != binaryMagic {
+		return 0, errCorrupt
+	}
+	if version := data[len(binaryMagic)]; version != binaryVersion {
+		return 0, fmt.Errorf("codoc: unsupported binary format version %d", version)
+	}
+	return data[len(binaryMagic)+1], nil
+}
+
+// binaryWriter encodes documentation values.
+// Strings are length prefixed, and slices and maps are prefixed by their length plus one,
+// zero meaning nil.
+type binaryWriter struct {
+	bytes.Buffer
+}
+
+func (w *binaryWriter) uvarint(v uint64) {
+	var buf [binary.MaxVarintLen64]byte
+	w.Write(buf[:binary.PutUvarint(buf[:], v)])
+}
+
+func (w *binaryWriter) str(s string) {
+	w.uvarint(uint64(len(s)))
+	w.WriteString(s)
+}
+
+func (w *binaryWriter) strs(list []string) {
+	if list == nil {
+		w.uvarint(0)
+		return
+	}
+	w.uvarint(uint64(len(list)) + 1)
+	for _, s := range list {
+		w.str(s)
+	}
+}
+
+func (w *binaryWriter) pkg(pkg Package) {
+	w.str(pkg.ID)
+	w.str(pkg.Name)
+	w.str(pkg.Doc)
+	w.str(pkg.Synopsis)
+	w.str(pkg.DocFile)
+
+	if pkg.Files == nil {
+		w.uvarint(0)
+	} else {
+		w.uvarint(uint64(len(pkg.Files)) + 1)
+		for _, f := range pkg.Files {
+			w.str(f.Name)
+			w.str(f.Doc)
+			w.str(f.Constraint)
+		}
+	}
+
+	w.funcs(pkg.Functions)
+
+	if pkg.Structs == nil {
+		w.uvarint(0)
+	} else {
+		w.uvarint(uint64(len(pkg.Structs)) + 1)
+		for _, k := range sortedKeys(pkg.Structs) {
+			st := pkg.Structs[k]
+			w.str(k)
+			w.str(st.Name)
+			w.str(st.Doc)
+			w.str(st.Synopsis)
+			w.fields(st.Fields)
+			w.funcs(st.Methods)
+		}
+	}
+}
+
+func (w *binaryWriter) funcs(m map[string]Function) {
+	if m == nil {
+		w.uvarint(0)
+		return
+	}
+	w.uvarint(uint64(len(m)) + 1)
+	for _, k := range sortedKeys(m) {
+		fn := m[k]
+		w.str(k)
+		w.str(fn.Name)
+		w.str(fn.Doc)
+		w.str(fn.Synopsis)
+		w.strs(fn.Args)
+		w.strs(fn.Results)
+	}
+}
+
+func (w *binaryWriter) fields(m map[string]Field) {
+	if m == nil {
+		w.uvarint(0)
+		return
+	}
+	w.uvarint(uint64(len(m)) + 1)
+	for _, k := range sortedKeys(m) {
+		f := m[k]
+		w.str(k)
+		w.str(f.Name)
+		w.str(f.Doc)
+		w.str(f.Comment)
+		w.str(f.Synopsis)
+	}
+}
+
+// binaryReader decodes documentation values written by binaryWriter.
+// The first error is kept, and every read after it returns zero values.
+type binaryReader struct {
+	data []byte
+	err  error
+}
+
+func (r *binaryReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		r.err = errCorrupt
+		return 0
+	}
+	r.data = r.data[n:]
+	return v
+}
+
+// count reads a length plus one prefix, reporting whether the value is nil.
+// Lengths are bounded by the remaining data, each item taking at least one byte.
+func (r *binaryReader) count() (int, bool) {
+	n := r.uvarint()
+	if n == 0 {
+		return 0, true
+	}
+	if n-1 > uint64(len(r.data)) {
+		r.err = errCorrupt
+		return 0, true
+	}
+	return int(n - 1), false
+}
+
+func (r *binaryReader) str() string {
+	n := r.uvarint()
+	if r.err != nil {
+		return ""
+	}
+	if n > uint64(len(r.data)) {
+		r.err = errCorrupt
+		return ""
+	}
+	s := string(r.data[:n])
+	r.data = r.data[n:]
+	return s
+}
+
+func (r *binaryReader) strs() []string {
+	n, isNil := r.count()
+	if isNil {
+		return nil
+	}
+	list := make([]string, n)
+	for i := range list {
+		list[i] = r.str()
+	}
+	return list
+}
+
+func (r *binaryReader) pkg() Package {
+	pkg := Package{
+		ID:       r.str(),
+		Name:     r.str(),
+		Doc:      r.str(),
+		Synopsis: r.str(),
+		DocFile:  r.str(),
+	}
+
+	if n, isNil := r.count(); !isNil {
+		pkg.Files = make([]File, n)
+		for i := range pkg.Files {
+			pkg.Files[i] = File{Name: r.str(), Doc: r.str(), Constraint: r.str()}
+		}
+	}
+
+	pkg.Functions = r.funcs()
+
+	if n, isNil := r.count(); !isNil {
+		pkg.Structs = make(map[string]Struct, n)
+		for i := 0; i < n && r.err == nil; i++ {
+			k := r.str()
+			pkg.Structs[k] = Struct{
+				Name:     r.str(),
+				Doc:      r.str(),
+				Synopsis: r.str(),
+				Fields:   r.fields(),
+				Methods:  r.funcs(),
+			}
+		}
+	}
+
+	return pkg
+}
+
+func (r *binaryReader) funcs() map[string]Function {
+	n, isNil := r.count()
+	if isNil {
+		return nil
+	}
+	m := make(map[string]Function, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		k := r.str()
+		m[k] = Function{
+			Name:     r.str(),
+			Doc:      r.str(),
+			Synopsis: r.str(),
+			Args:     r.strs(),
+			Results:  r.strs(),
+		}
+	}
+	return m
+}
+
+func (r *binaryReader) fields() map[string]Field {
+	n, isNil := r.count()
+	if isNil {
+		return nil
+	}
+	m := make(map[string]Field, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		k := r.str()
+		m[k] = Field{
+			Name:     r.str(),
+			Doc:      r.str(),
+			Comment:  r.str(),
+			Synopsis: r.str(),
+		}
+	}
+	return m
+}
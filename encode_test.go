@@ -0,0 +1,87 @@
+package codoc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodeTestPackage() Package {
+	return Package{
+		ID:       "example.com/enc",
+		Name:     "enc",
+		Doc:      "Package enc is encoded.",
+		Synopsis: "Package enc is encoded.",
+		DocFile:  "doc.go",
+		Files:    []File{{Name: "doc.go", Constraint: "linux"}, {Name: "enc.go", Doc: "Copyright"}},
+		Functions: map[string]Function{
+			"Encode": {Name: "Encode", Doc: "Encode encodes.", Args: []string{"v"}, Results: []string{}},
+			"decode": {Name: "decode"},
+		},
+		Structs: map[string]Struct{
+			"Codec": {
+				Name:    "Codec",
+				Doc:     "Codec is a codec.",
+				Fields:  map[string]Field{"Level": {Name: "Level", Comment: "Compression level", Synopsis: "Compression level"}},
+				Methods: map[string]Function{"Close": {Name: "Close", Results: []string{"err"}}},
+			},
+			"empty": {Name: "empty", Fields: map[string]Field{}},
+		},
+	}
+}
+
+func TestEncodeBinary(t *testing.T) {
+	pkg := encodeTestPackage()
+
+	for _, compress := range []bool{false, true} {
+		data, err := EncodeBinary(pkg, compress)
+		require.NoError(t, err, "Encoding should succeed, compress=%v", compress)
+
+		decoded, err := DecodeBinary(data)
+		require.NoError(t, err, "Decoding should succeed, compress=%v", compress)
+		assert.Equal(t, pkg, decoded, "Decoded package should equal the original, compress=%v", compress)
+
+		again, err := EncodeBinary(pkg, compress)
+		require.NoError(t, err)
+		assert.Equal(t, data, again, "Encoding should be deterministic, compress=%v", compress)
+	}
+
+	decoded, err := DecodeBinary(mustEncode(t, Package{ID: "example.com/nil"}, false))
+	require.NoError(t, err)
+	assert.Equal(t, Package{ID: "example.com/nil"}, decoded, "Nil slices and maps should stay nil")
+}
+
+func TestDecodeBinaryErrors(t *testing.T) {
+	data := mustEncode(t, encodeTestPackage(), false)
+
+	_, err := DecodeBinary(nil)
+	assert.Error(t, err, "Empty data should fail")
+
+	_, err = DecodeBinary([]byte("nope!\x01\x00"))
+	assert.Error(t, err, "Bad magic should fail")
+
+	bad := append([]byte(nil), data...)
+	bad[len(binaryMagic)] = 99
+	_, err = DecodeBinary(bad)
+	assert.ErrorContains(t, err, "version 99", "Unknown versions should fail")
+
+	for _, n := range []int{len(data) - 1, len(data) / 2, len(binaryMagic) + 3} {
+		_, err = DecodeBinary(data[:n])
+		assert.Error(t, err, "Truncated data should fail, length %d", n)
+	}
+
+	_, err = DecodeBinary(append(data, 0))
+	assert.Error(t, err, "Trailing data should fail")
+
+	compressed := mustEncode(t, encodeTestPackage(), true)
+	_, err = DecodeBinary(compressed[:len(compressed)-4])
+	assert.Error(t, err, "Truncated compressed data should fail")
+}
+
+func mustEncode(t *testing.T, pkg Package, compress bool) []byte {
+	t.Helper()
+	data, err := EncodeBinary(pkg, compress)
+	require.NoError(t, err)
+	return data
+}
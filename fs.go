@@ -0,0 +1,81 @@
+package codoc
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// RegisterFS registers the packages of the documents in fsys matching the glob pattern
+// with the default registry. See Registry.RegisterFS.
+func RegisterFS(fsys fs.FS, pattern string) error { return Default.RegisterFS(fsys, pattern) }
+
+// RegisterFS registers the packages of the documents in fsys matching the glob pattern,
+// like an embed.FS holding files written by codoc with -format json or yaml.
+// Files ending in .json are read as JSON, and files ending in .yaml or .yml as YAML.
+// An additional .gz extension, like docs.json.gz, marks gzip compressed files.
+// Matching directories are skipped. Every document is read before any package is
+// registered, so nothing is registered if a file cannot be read, or if nothing matches.
+func (r *Registry) RegisterFS(fsys fs.FS, pattern string) error {
+	names, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return fmt.Errorf("codoc: %v", err)
+	}
+
+	var docs []*Document
+	for _, name := range names {
+		if info, err := fs.Stat(fsys, name); err == nil && info.IsDir() {
+			continue
+		}
+
+		doc, err := readFSDocument(fsys, name)
+		if err != nil {
+			return fmt.Errorf("codoc: read %s: %v", name, err)
+		}
+		docs = append(docs, doc)
+	}
+	if len(docs) == 0 {
+		return fmt.Errorf("codoc: no documents match %q", pattern)
+	}
+
+	for _, doc := range docs {
+		r.RegisterDocument(*doc)
+	}
+	return nil
+}
+
+// readFSDocument reads a document from fsys, picking the decoder based on the file extension.
+func readFSDocument(fsys fs.FS, name string) (*Document, error) {
+	base, gzipped := strings.CutSuffix(name, ".gz")
+
+	var decode func(io.Reader) (*Document, error)
+	switch ext := path.Ext(base); ext {
+	case ".json":
+		decode = DecodeJSON
+	case ".yaml", ".yml":
+		decode = DecodeYAML
+	default:
+		return nil, fmt.Errorf("unsupported file extension %q", ext)
+	}
+
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var rd io.Reader = f
+	if gzipped {
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		rd = zr
+	}
+
+	return decode(rd)
+}
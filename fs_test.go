@@ -0,0 +1,54 @@
+package codoc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterFS(t *testing.T) {
+	var jsonDoc, yamlDoc, gz bytes.Buffer
+	require.NoError(t, EncodeJSON(&jsonDoc, NewDocument(Package{ID: "example.com/j", Name: "j", Functions: map[string]Function{"F": {Name: "F"}}})))
+	require.NoError(t, EncodeYAML(&yamlDoc, NewDocument(Package{ID: "example.com/y", Name: "y"})))
+
+	zw := gzip.NewWriter(&gz)
+	require.NoError(t, EncodeJSON(zw, NewDocument(encodeTestPackage(), Package{ID: "example.com/z", Name: "z"})))
+	require.NoError(t, zw.Close())
+
+	fsys := fstest.MapFS{
+		"docs/j.json":    {Data: jsonDoc.Bytes()},
+		"docs/y.yml":     {Data: yamlDoc.Bytes()},
+		"docs/z.json.gz": {Data: gz.Bytes()},
+		"docs/sub/a.txt": {Data: []byte("ignored")},
+	}
+
+	r := NewRegistry()
+	require.NoError(t, r.RegisterFS(fsys, "docs/*"), "Documents should be registered, skipping directories")
+	assert.NotNil(t, r.GetFunction("example.com/j.F"), "JSON documents should be registered")
+	assert.NotNil(t, r.GetPackage("example.com/y"), "YAML documents should be registered")
+	assert.NotNil(t, r.GetStruct("example.com/enc.Codec"), "Compressed documents should be registered")
+	assert.NotNil(t, r.GetPackage("example.com/z"), "Compressed documents can hold several packages")
+}
+
+func TestRegisterFSErrors(t *testing.T) {
+	fsys := fstest.MapFS{
+		"good.json":      {Data: []byte(`{"version": 1, "packages": [{"id": "example.com/good", "name": "good"}]}`)},
+		"bad.json":       {Data: []byte(`{"version": 1, "packages": [`)},
+		"doc.txt":        {Data: []byte("text")},
+		"broken.yaml.gz": {Data: []byte("not gzip")},
+	}
+
+	r := NewRegistry()
+	err := r.RegisterFS(fsys, "*.json")
+	assert.ErrorContains(t, err, "bad.json", "Errors should name the file")
+	assert.Nil(t, r.GetPackage("example.com/good"), "Nothing should be registered on error")
+
+	assert.ErrorContains(t, r.RegisterFS(fsys, "*.txt"), "unsupported file extension", "Unknown extensions should fail")
+	assert.ErrorContains(t, r.RegisterFS(fsys, "*.gz"), "broken.yaml.gz", "Invalid gzip data should fail")
+	assert.ErrorContains(t, r.RegisterFS(fsys, "*.yaml"), "no documents match", "Patterns matching nothing should fail")
+	assert.Error(t, r.RegisterFS(fsys, "["), "Malformed patterns should fail")
+}
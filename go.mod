@@ -6,6 +6,7 @@ require (
 	github.com/alecthomas/repr v0.4.0
 	github.com/stretchr/testify v1.10.0
 	golang.org/x/tools v0.21.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -13,5 +14,4 @@ require (
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	golang.org/x/mod v0.17.0 // indirect
 	golang.org/x/sync v0.7.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
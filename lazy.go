@@ -0,0 +1,109 @@
+package codoc
+
+import "fmt"
+
+// lazyPackage is a package registered with RegisterLazy, kept encoded until first needed.
+type lazyPackage struct {
+	id   string // Import path of the package
+	data string // Package encoded with EncodeBinary
+}
+
+// RegisterLazy adds a package encoded with EncodeBinary to the default registry,
+// deferring decoding until it is first needed. See Registry.RegisterLazy.
+func RegisterLazy(id, name, data string) { Default.RegisterLazy(id, name, data) }
+
+// RegisterLazy adds a package encoded with EncodeBinary to the registry, deferring
+// decoding until it is first needed. id and name are the package's import path and name,
+// which determine the ID it is registered under without decoding it.
+// Looking up an item decodes only the package it belongs to, while listing, walking,
+// searching and resolving decode every pending package.
+// A package already registered under the same ID is replaced.
+// Packages whose data is corrupt are dropped, either right away if their header is invalid
+// or when decoded, so that looking them up finds nothing. See LazyErrors.
+func (r *Registry) RegisterLazy(id, name, data string) {
+	regID := pkgID(Package{ID: id, Name: name})
+
+	header := data
+	if len(header) > binaryHeaderLen {
+		header = header[:binaryHeaderLen]
+	}
+	_, err := readHeader([]byte(header))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.remove(regID)
+	if err != nil {
+		r.lazyErrs[regID] = fmt.Errorf("codoc: register package %q: %w", id, err)
+		return
+	}
+	r.lazy[regID] = lazyPackage{id: id, data: data}
+}
+
+// LazyErrors returns the errors of the packages registered with RegisterLazy in the
+// default registry that were dropped because their data is corrupt. See Registry.LazyErrors.
+func LazyErrors() []error { return Default.LazyErrors() }
+
+// LazyErrors returns the errors of the packages registered with RegisterLazy that were
+// dropped because their data is corrupt, sorted by package ID. Registering or unregistering
+// a package clears its error. Call it after loading pending packages, like with Packages,
+// to check every package at once.
+func (r *Registry) LazyErrors() []error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	errs := make([]error, 0, len(r.lazyErrs))
+	for _, id := range sortedKeys(r.lazyErrs) {
+		errs = append(errs, r.lazyErrs[id])
+	}
+	return errs
+}
+
+// loadAll decodes and registers every lazily registered package.
+func (r *Registry) loadAll() {
+	r.mu.RLock()
+	ids := make([]string, 0, len(r.lazy))
+	for id := range r.lazy {
+		ids = append(ids, id)
+	}
+	r.mu.RUnlock()
+
+	r.loadLazy(ids...)
+}
+
+// loadLazy decodes and registers the lazily registered packages with the given IDs.
+// Pending packages are looked up under the read lock, so that the write lock is only
+// taken when one of them actually needs decoding. Reports whether any of them was pending.
+func (r *Registry) loadLazy(ids ...string) bool {
+	r.mu.RLock()
+	var pending []string
+	for _, id := range ids {
+		if _, ok := r.lazy[id]; ok {
+			pending = append(pending, id)
+		}
+	}
+	r.mu.RUnlock()
+	if len(pending) == 0 {
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, id := range pending {
+		// Skip packages decoded or unregistered since the read lock was released
+		lp, ok := r.lazy[id]
+		if !ok {
+			continue
+		}
+
+		delete(r.lazy, id)
+		pkg, err := DecodeBinary([]byte(lp.data))
+		if err != nil {
+			r.lazyErrs[id] = fmt.Errorf("codoc: decode package %q: %w", lp.id, err)
+			continue
+		}
+		r.add(id, pkg, indexPackage(id, pkg), searchTerms(id, pkg))
+	}
+	return true
+}
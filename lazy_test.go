@@ -0,0 +1,114 @@
+package codoc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func registerLazy(t *testing.T, r *Registry, pkg Package) {
+	t.Helper()
+	r.RegisterLazy(pkg.ID, pkg.Name, string(mustEncode(t, pkg, true)))
+}
+
+func TestRegisterLazy(t *testing.T) {
+	r := NewRegistry()
+	registerLazy(t, r, encodeTestPackage())
+	registerLazy(t, r, Package{ID: "example.com/enc/other", Name: "other", Functions: map[string]Function{"F": {Name: "F"}}})
+	assert.Len(t, r.lazy, 2, "Packages should not be decoded when registered")
+
+	fn := r.GetFunction("example.com/enc.Codec.Close")
+	require.NotNil(t, fn, "Method should be found in a lazy package")
+	assert.Equal(t, []string{"err"}, fn.Results)
+	assert.Len(t, r.lazy, 1, "Only the package of the looked up item should be decoded")
+	assert.Contains(t, r.lazy, "example.com/enc/other")
+
+	assert.Nil(t, r.GetStruct("example.com/missing.T"), "Missing items should not decode anything")
+	assert.Len(t, r.lazy, 1)
+
+	require.NotNil(t, r.GetPackage("example.com/enc/other"), "Package should be found by ID")
+	assert.Empty(t, r.lazy, "Every package should be decoded")
+}
+
+func TestRegisterLazyListing(t *testing.T) {
+	r := NewRegistry()
+	registerLazy(t, r, encodeTestPackage())
+	assert.Len(t, r.Packages(), 1, "Listing should decode pending packages")
+
+	r = NewRegistry()
+	registerLazy(t, r, encodeTestPackage())
+	assert.NotEmpty(t, r.Search("codec"), "Search should decode pending packages")
+
+	r = NewRegistry()
+	registerLazy(t, r, encodeTestPackage())
+	e, err := r.Resolve("enc.Encode")
+	require.NoError(t, err, "Resolve should decode pending packages")
+	assert.Equal(t, "example.com/enc.Encode", e.ID)
+}
+
+func TestRegisterLazyReplace(t *testing.T) {
+	r := NewRegistry()
+	r.Register(Package{ID: "example.com/enc", Name: "enc", Functions: map[string]Function{"Old": {Name: "Old"}}})
+	registerLazy(t, r, encodeTestPackage())
+
+	assert.Nil(t, r.GetFunction("example.com/enc.Old"), "Lazy registration should replace the registered package")
+	assert.NotNil(t, r.GetFunction("example.com/enc.Encode"))
+
+	registerLazy(t, r, Package{ID: "example.com/enc2", Name: "enc2"})
+	err := r.TryRegister(Package{ID: "example.com/enc2", Name: "enc2"})
+	var conflict *ConflictError
+	require.ErrorAs(t, err, &conflict, "Pending packages should conflict")
+
+	assert.True(t, r.Unregister("example.com/enc2"), "Pending packages can be unregistered")
+	assert.Nil(t, r.GetPackage("example.com/enc2"))
+}
+
+func TestRegisterLazyMain(t *testing.T) {
+	r := NewRegistry()
+	registerLazy(t, r, Package{ID: "example.com/cmd/tool", Name: "main", Functions: map[string]Function{"run": {Name: "run"}}})
+	assert.NotNil(t, r.GetFunction("main.run"), "Main packages should be registered as main")
+}
+
+func TestRegisterLazyCorrupt(t *testing.T) {
+	r := NewRegistry()
+	registerLazy(t, r, Package{ID: "example.com/good", Name: "good", Functions: map[string]Function{"F": {Name: "F"}}})
+	r.RegisterLazy("example.com/bad", "bad", "codoc\x01\x00\xff")
+	assert.Empty(t, r.LazyErrors(), "Data should not be decoded when registered")
+
+	assert.NotPanics(t, func() {
+		assert.Nil(t, r.GetFunction("example.com/bad.F"), "Corrupt packages should not be found")
+	}, "Corrupt data should not panic")
+	assert.Nil(t, r.GetPackage("example.com/bad"), "Corrupt packages should be dropped")
+	assert.Empty(t, r.lazy["example.com/bad"], "Corrupt packages should be dropped")
+	require.Len(t, r.LazyErrors(), 1, "Corrupt packages should be reported")
+	assert.ErrorContains(t, r.LazyErrors()[0], `decode package "example.com/bad"`)
+	assert.ErrorIs(t, r.LazyErrors()[0], errCorrupt)
+
+	r.RegisterLazy("example.com/header", "header", "json{}")
+	r.RegisterLazy("example.com/version", "version", "codoc\x09\x00")
+	r.RegisterLazy("example.com/empty", "empty", "")
+	assert.Len(t, r.lazy, 1, "Invalid headers should be rejected when registered")
+	assert.Len(t, r.LazyErrors(), 4, "Invalid headers should be reported")
+	assert.ErrorContains(t, r.LazyErrors()[3], "unsupported binary format version 9")
+
+	assert.Len(t, r.Packages(), 1, "Valid packages should still load")
+	assert.NotNil(t, r.GetFunction("example.com/good.F"))
+
+	r.Register(Package{ID: "example.com/bad", Name: "bad"})
+	r.Unregister("example.com/header")
+	assert.Len(t, r.LazyErrors(), 2, "Registering and unregistering should clear errors")
+}
+
+func TestRegisterLazyMiss(t *testing.T) {
+	r := NewRegistry()
+	registerLazy(t, r, encodeTestPackage())
+	registerLazy(t, r, Package{ID: "example.com/enc/sub", Name: "sub", Functions: map[string]Function{"F": {Name: "F"}}})
+
+	assert.Nil(t, r.GetFunction("example.com/other.F"), "Unrelated IDs should not be found")
+	assert.Nil(t, r.GetFunction("example.com/encoder.F"), "IDs sharing a prefix with a package should not be found")
+	assert.Len(t, r.lazy, 2, "Misses should not decode unrelated packages")
+
+	assert.NotNil(t, r.GetFunction("example.com/enc/sub.F"), "Nested package IDs should be found")
+	assert.Len(t, r.lazy, 1, "Only the owning package should be decoded")
+}
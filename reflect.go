@@ -0,0 +1,232 @@
+package codoc
+
+import (
+	"net/url"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// TypeOf retrieves the documentation of the struct type of v from the default registry.
+// Pointers are dereferenced, so TypeOf(T{}) and TypeOf(&T{}) are equivalent.
+// Returns nil if v is nil, its type is unnamed, or it is not registered.
+func TypeOf(v any) *Struct { return Default.TypeOf(v) }
+
+// ForType retrieves the documentation of a struct type from the default registry.
+// Returns nil if the type is unnamed or not registered.
+func ForType(t reflect.Type) *Struct { return Default.ForType(t) }
+
+// FuncOf retrieves the documentation of the function or method fn from the default registry.
+// Returns nil if fn is not a function, or it is not registered.
+func FuncOf(fn any) *Function { return Default.FuncOf(fn) }
+
+// FieldOf retrieves the documentation of a struct field from the default registry.
+// Returns nil if the declaring struct or the field is not registered.
+func FieldOf(owner reflect.Type, sf reflect.StructField) *Field { return Default.FieldOf(owner, sf) }
+
+// FieldByPath resolves a dotted path of field names starting from the struct type t,
+// and returns the field documentation and its declaring struct from the default registry.
+// Returns nil values if the path cannot be resolved, or the field is not registered.
+func FieldByPath(t reflect.Type, path string) (*Field, *Struct) { return Default.FieldByPath(t, path) }
+
+// TypeOf retrieves the documentation of the struct type of v from the registry.
+// Pointers are dereferenced, so TypeOf(T{}) and TypeOf(&T{}) are equivalent.
+// Returns nil if v is nil, its type is unnamed, or it is not registered.
+func (r *Registry) TypeOf(v any) *Struct {
+	if v == nil {
+		return nil
+	}
+	return r.ForType(reflect.TypeOf(v))
+}
+
+// ForType retrieves the documentation of a struct type from the registry.
+// The ID is derived from the type's package path and name, so types declared
+// in main packages are found under "main", as they are registered.
+// Returns nil if the type is unnamed or not registered.
+func (r *Registry) ForType(t reflect.Type) *Struct {
+	id := TypeID(t)
+	if id == "" {
+		return nil
+	}
+	return r.GetStruct(id)
+}
+
+// TypeID returns the registry ID of a type, like "example.com/pkg.Type".
+// Pointers are dereferenced and type arguments of generic types are dropped,
+// so *Set[int] and Set[string] share the ID of Set.
+// Returns an empty string for unnamed and predeclared types.
+func TypeID(t reflect.Type) string {
+	if t == nil {
+		return ""
+	}
+	t = derefType(t)
+
+	name, pkgPath := t.Name(), t.PkgPath()
+	if name == "" || pkgPath == "" {
+		return ""
+	}
+
+	// Instantiated generic types are named like "Set[int]"
+	if i := strings.IndexByte(name, '['); i >= 0 {
+		name = name[:i]
+	}
+
+	return pkgPath + "." + name
+}
+
+// FuncOf retrieves the documentation of the function or method fn from the registry.
+// fn may be a function, a method expression like (*T).M, or a method value like t.M.
+// Returns nil if fn is not a function, or it is not registered.
+func (r *Registry) FuncOf(fn any) *Function {
+	id := FuncID(fn)
+	if id == "" {
+		return nil
+	}
+	return r.GetFunction(id)
+}
+
+// FuncID returns the registry ID of a function or method, like "example.com/pkg.Func"
+// or "example.com/pkg.Type.Method", derived from its runtime symbol name.
+// Returns an empty string if fn is nil or not a function.
+func FuncID(fn any) string {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return ""
+	}
+
+	rfn := runtime.FuncForPC(v.Pointer())
+	if rfn == nil {
+		return ""
+	}
+	return funcID(rfn.Name())
+}
+
+// funcID converts a runtime function name to a registry ID.
+// Runtime names look like "example.com/pkg.Func", "example.com/pkg.(*Type).Method",
+// or "example.com/pkg.Type.Method-fm" for method values, with dots in the last
+// element of the package path escaped as "%2e".
+func funcID(name string) string {
+	// The package path ends at the first dot after the last slash
+	slash := strings.LastIndexByte(name, '/')
+	dot := strings.IndexByte(name[slash+1:], '.')
+	if dot == -1 {
+		return ""
+	}
+	dot += slash + 1
+	pkgPath, sym := name[:dot], name[dot+1:]
+
+	if unescaped, err := url.PathUnescape(pkgPath); err == nil {
+		pkgPath = unescaped
+	}
+
+	// Method values are wrapped by functions with a "-fm" suffix
+	sym = strings.TrimSuffix(sym, "-fm")
+
+	// Pointer receivers are written as "(*Type)"
+	sym = strings.ReplaceAll(sym, "(*", "")
+	sym = strings.ReplaceAll(sym, ")", "")
+
+	// Type arguments of generic functions and types are written as "[...]"
+	var sb strings.Builder
+	depth := 0
+	for _, r := range sym {
+		switch {
+		case r == '[':
+			depth++
+		case r == ']':
+			depth--
+		case depth == 0:
+			sb.WriteRune(r)
+		}
+	}
+
+	return pkgPath + "." + sb.String()
+}
+
+// FieldOf retrieves the documentation of a struct field from the registry.
+// owner is the struct type sf was obtained from; fields promoted from embedded
+// structs, as returned by reflect.Type.FieldByName, are looked up in the struct
+// that declares them.
+// Returns nil if the declaring struct or the field is not registered.
+func (r *Registry) FieldOf(owner reflect.Type, sf reflect.StructField) *Field {
+	declaring, ok := declaringType(owner, sf.Index)
+	if !ok {
+		return nil
+	}
+	return r.GetField(TypeID(declaring) + "." + sf.Name)
+}
+
+// FieldByPath resolves a dotted path of field names, like "Config.Server.Timeout",
+// starting from the struct type t, and returns the documentation of the last field
+// along with the struct declaring it.
+// Each element may name a field promoted from an embedded struct, and pointers are
+// dereferenced along the way.
+// Returns nil values if the path cannot be resolved, or the field is not registered.
+func (r *Registry) FieldByPath(t reflect.Type, path string) (*Field, *Struct) {
+	if t == nil || path == "" {
+		return nil, nil
+	}
+
+	names := strings.Split(path, ".")
+	for i, name := range names {
+		t = derefType(t)
+		if t.Kind() != reflect.Struct {
+			return nil, nil
+		}
+
+		sf, ok := t.FieldByName(name)
+		if !ok {
+			return nil, nil
+		}
+
+		if i < len(names)-1 {
+			t = sf.Type
+			continue
+		}
+
+		declaring, ok := declaringType(t, sf.Index)
+		if !ok {
+			return nil, nil
+		}
+		st := r.ForType(declaring)
+		if st == nil {
+			return nil, nil
+		}
+		field, ok := st.Fields[sf.Name]
+		if !ok {
+			return nil, nil
+		}
+		return &field, st
+	}
+
+	return nil, nil
+}
+
+// declaringType follows the index sequence of a possibly promoted field through
+// embedded structs and returns the struct type that declares the field.
+func declaringType(t reflect.Type, index []int) (reflect.Type, bool) {
+	if t == nil || len(index) == 0 {
+		return nil, false
+	}
+
+	t = derefType(t)
+	for _, i := range index[:len(index)-1] {
+		if t.Kind() != reflect.Struct || i >= t.NumField() {
+			return nil, false
+		}
+		t = derefType(t.Field(i).Type)
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil, false
+	}
+	return t, true
+}
+
+// derefType dereferences pointer types until reaching a non-pointer type.
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
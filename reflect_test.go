@@ -0,0 +1,226 @@
+package codoc
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// reflectType is a struct used to test lookups by type
+type reflectType struct{}
+
+// reflectGeneric is a generic struct used to test lookups by type
+type reflectGeneric[T any] struct{ v T }
+
+func TestTypeID(t *testing.T) {
+	pkgPath := reflect.TypeOf(reflectType{}).PkgPath()
+
+	assert.Equal(t, pkgPath+".reflectType", TypeID(reflect.TypeOf(reflectType{})), "Type ID mismatch")
+	assert.Equal(t, pkgPath+".reflectType", TypeID(reflect.TypeOf(&reflectType{})), "Pointer type ID mismatch")
+	assert.Equal(t, pkgPath+".reflectGeneric", TypeID(reflect.TypeOf(reflectGeneric[int]{})), "Generic type ID mismatch")
+	assert.Equal(t, "", TypeID(reflect.TypeOf(struct{}{})), "Unnamed type should have no ID")
+	assert.Equal(t, "", TypeID(reflect.TypeOf(0)), "Predeclared type should have no ID")
+	assert.Equal(t, "", TypeID(nil), "Nil type should have no ID")
+}
+
+func TestTypeOf(t *testing.T) {
+	pkgPath := reflect.TypeOf(reflectType{}).PkgPath()
+	Register(Package{
+		ID:   pkgPath,
+		Name: "codoc",
+		Structs: map[string]Struct{
+			"reflectType":    {Name: "reflectType", Doc: "reflectType doc"},
+			"reflectGeneric": {Name: "reflectGeneric", Doc: "reflectGeneric doc"},
+		},
+	})
+
+	st := TypeOf(reflectType{})
+	require.NotNil(t, st, "TypeOf returned nil for registered struct")
+	assert.Equal(t, "reflectType doc", st.Doc, "Struct doc mismatch")
+
+	st = TypeOf(&reflectType{})
+	require.NotNil(t, st, "TypeOf returned nil for pointer to registered struct")
+	assert.Equal(t, "reflectType", st.Name, "Struct name mismatch")
+
+	st = ForType(reflect.TypeOf(reflectGeneric[string]{}))
+	require.NotNil(t, st, "ForType returned nil for instantiated generic struct")
+	assert.Equal(t, "reflectGeneric doc", st.Doc, "Struct doc mismatch")
+
+	assert.Nil(t, TypeOf(nil), "TypeOf should return nil for nil")
+	assert.Nil(t, TypeOf(struct{}{}), "TypeOf should return nil for unnamed types")
+}
+
+// reflectFunc is a function used to test lookups by function value
+func reflectFunc() {}
+
+// reflectGenericFunc is a generic function used to test lookups by function value
+func reflectGenericFunc[T any](v T) T { return v }
+
+// ValueMethod is a method with a value receiver
+func (reflectType) ValueMethod() {}
+
+// PointerMethod is a method with a pointer receiver
+func (*reflectType) PointerMethod() {}
+
+// Get is a method of a generic type
+func (g *reflectGeneric[T]) Get() T { return g.v }
+
+func TestFuncID(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+	}{
+		{"example.com/pkg.Func", "example.com/pkg.Func"},
+		{"example.com/pkg.Type.Method", "example.com/pkg.Type.Method"},
+		{"example.com/pkg.(*Type).Method", "example.com/pkg.Type.Method"},
+		{"example.com/pkg.(*Type).Method-fm", "example.com/pkg.Type.Method"},
+		{"example.com/pkg.Type.Method-fm", "example.com/pkg.Type.Method"},
+		{"example.com/pkg.Func[...]", "example.com/pkg.Func"},
+		{"example.com/pkg.(*Type[...]).Method", "example.com/pkg.Type.Method"},
+		{"example.com/pkg%2ev2.Func", "example.com/pkg.v2.Func"},
+		{"main.Func", "main.Func"},
+		{"nodot", ""},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.id, funcID(tt.name), "ID for runtime name %q", tt.name)
+	}
+
+	assert.Equal(t, "", FuncID(nil), "Nil should have no ID")
+	assert.Equal(t, "", FuncID(42), "Non-function should have no ID")
+}
+
+func TestFuncOf(t *testing.T) {
+	pkgPath := reflect.TypeOf(reflectType{}).PkgPath()
+	Register(Package{
+		ID:   pkgPath,
+		Name: "codoc",
+		Functions: map[string]Function{
+			"reflectFunc":        {Name: "reflectFunc", Doc: "reflectFunc doc"},
+			"reflectGenericFunc": {Name: "reflectGenericFunc", Doc: "reflectGenericFunc doc"},
+		},
+		Structs: map[string]Struct{
+			"reflectType": {
+				Name: "reflectType",
+				Methods: map[string]Function{
+					"ValueMethod":   {Name: "ValueMethod", Doc: "ValueMethod doc"},
+					"PointerMethod": {Name: "PointerMethod", Doc: "PointerMethod doc"},
+				},
+			},
+			"reflectGeneric": {
+				Name: "reflectGeneric",
+				Methods: map[string]Function{
+					"Get": {Name: "Get", Doc: "Get doc"},
+				},
+			},
+		},
+	})
+
+	var rt reflectType
+	var rg reflectGeneric[int]
+	tests := []struct {
+		fn  any
+		doc string
+	}{
+		{reflectFunc, "reflectFunc doc"},
+		{reflectGenericFunc[int], "reflectGenericFunc doc"},
+		{reflectType.ValueMethod, "ValueMethod doc"},
+		{(*reflectType).PointerMethod, "PointerMethod doc"},
+		{rt.ValueMethod, "ValueMethod doc"},
+		{rt.PointerMethod, "PointerMethod doc"},
+		{rg.Get, "Get doc"},
+	}
+
+	for _, tt := range tests {
+		fn := FuncOf(tt.fn)
+		if assert.NotNil(t, fn, "FuncOf returned nil for %s (%s)", tt.doc, FuncID(tt.fn)) {
+			assert.Equal(t, tt.doc, fn.Doc, "Function doc mismatch")
+		}
+	}
+
+	assert.Nil(t, FuncOf(func() {}), "FuncOf should return nil for closures")
+	assert.Nil(t, FuncOf(nil), "FuncOf should return nil for nil")
+}
+
+// fieldInner is embedded in fieldOuter
+type fieldInner struct {
+	Timeout int
+}
+
+// fieldNested is referenced by a field of fieldOuter
+type fieldNested struct {
+	Addr string
+}
+
+// fieldOuter is a struct used to test field lookups
+type fieldOuter struct {
+	*fieldInner
+	Server fieldNested
+	Name   string
+}
+
+func TestFieldLookups(t *testing.T) {
+	pkgPath := reflect.TypeOf(fieldOuter{}).PkgPath()
+	Register(Package{
+		ID:   pkgPath,
+		Name: "codoc",
+		Structs: map[string]Struct{
+			"fieldOuter": {
+				Name: "fieldOuter",
+				Fields: map[string]Field{
+					"Name":   {Name: "Name", Doc: "Name doc"},
+					"Server": {Name: "Server", Doc: "Server doc"},
+				},
+			},
+			"fieldInner": {
+				Name: "fieldInner",
+				Fields: map[string]Field{
+					"Timeout": {Name: "Timeout", Doc: "Timeout doc"},
+				},
+			},
+			"fieldNested": {
+				Name: "fieldNested",
+				Fields: map[string]Field{
+					"Addr": {Name: "Addr", Doc: "Addr doc"},
+				},
+			},
+		},
+	})
+
+	outer := reflect.TypeOf(&fieldOuter{})
+
+	// Direct and promoted fields by reflect.StructField
+	sf, _ := outer.Elem().FieldByName("Name")
+	field := FieldOf(outer, sf)
+	require.NotNil(t, field, "FieldOf returned nil for direct field")
+	assert.Equal(t, "Name doc", field.Doc, "Field doc mismatch")
+
+	sf, _ = outer.Elem().FieldByName("Timeout")
+	field = FieldOf(outer, sf)
+	require.NotNil(t, field, "FieldOf returned nil for promoted field")
+	assert.Equal(t, "Timeout doc", field.Doc, "Field doc mismatch")
+
+	// Paths through nested and embedded fields
+	field, st := FieldByPath(outer, "Server.Addr")
+	require.NotNil(t, field, "FieldByPath returned nil for nested field")
+	assert.Equal(t, "Addr doc", field.Doc, "Field doc mismatch")
+	assert.Equal(t, "fieldNested", st.Name, "Owning struct mismatch")
+
+	field, st = FieldByPath(outer, "Timeout")
+	require.NotNil(t, field, "FieldByPath returned nil for promoted field")
+	assert.Equal(t, "Timeout doc", field.Doc, "Field doc mismatch")
+	assert.Equal(t, "fieldInner", st.Name, "Owning struct mismatch")
+
+	field, st = FieldByPath(outer, "Server")
+	require.NotNil(t, field, "FieldByPath returned nil for direct field")
+	assert.Equal(t, "fieldOuter", st.Name, "Owning struct mismatch")
+
+	field, st = FieldByPath(outer, "Name.Missing")
+	assert.Nil(t, field, "FieldByPath should return nil through non-struct fields")
+	assert.Nil(t, st, "FieldByPath should return nil through non-struct fields")
+
+	field, _ = FieldByPath(outer, "Missing")
+	assert.Nil(t, field, "FieldByPath should return nil for missing fields")
+}
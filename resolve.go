@@ -0,0 +1,133 @@
+package codoc
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrNotFound is returned by Resolve when no registered item matches a name.
+var ErrNotFound = errors.New("codoc: not found")
+
+// AmbiguousError is returned by Resolve when a name matches several registered items.
+type AmbiguousError struct {
+	Name       string   // Name that was resolved
+	Candidates []string // IDs of the matching items, sorted
+}
+
+// Error implements the error interface for AmbiguousError.
+func (e *AmbiguousError) Error() string {
+	return fmt.Sprintf("codoc: %q is ambiguous, it could be any of %s", e.Name, strings.Join(e.Candidates, ", "))
+}
+
+// Resolve finds an item in the default registry by a possibly abbreviated name, see Registry.Resolve.
+func Resolve(name string) (Entry, error) { return Default.Resolve(name) }
+
+// Resolve finds a registered item by a possibly abbreviated name, the way go doc does.
+// Besides full IDs, it accepts IDs qualified by package name or by a trailing part of the
+// import path instead of the full import path, like "codocgen.FromPath" or
+// "codoc/codocgen.FromPath", bare names within a package, like "FromPath" or
+// "PackageError.Error", and bare method and field names, like "Error".
+// Lowercase letters in name also match uppercase ones, so "frompath" matches FromPath.
+// More precise matches are preferred: full IDs over qualified or bare IDs, over bare method
+// and field names, and case-sensitive matches over case-insensitive ones.
+// Returns an error wrapping ErrNotFound if nothing matches, and an *AmbiguousError
+// listing the candidates if several items match equally well.
+func (r *Registry) Resolve(name string) (Entry, error) {
+	r.loadAll()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if e, ok := r.entryLocked(name); ok {
+		return e, nil
+	}
+
+	for _, fold := range []bool{false, true} {
+		for _, members := range []bool{false, true} {
+			var found []Entry
+			for id, pkg := range r.pkgs {
+				if !members && matchPackage(name, id, pkg.Name, fold) {
+					found = append(found, pkgEntry(id, pkg))
+				}
+			}
+			for id, e := range r.index {
+				if r.matchEntry(name, id, e, members, fold) {
+					found = append(found, e.entry(id))
+				}
+			}
+
+			switch len(found) {
+			case 0:
+				continue
+			case 1:
+				return found[0], nil
+			}
+
+			ids := make([]string, len(found))
+			for i, e := range found {
+				ids[i] = e.ID
+			}
+			sort.Strings(ids)
+			return Entry{}, &AmbiguousError{Name: name, Candidates: ids}
+		}
+	}
+
+	return Entry{}, fmt.Errorf("%w: %q", ErrNotFound, name)
+}
+
+// matchEntry reports whether name refers to an indexed item. The caller must hold the lock.
+// If members is false, name must be the ID of the item within its package, optionally
+// qualified by the package; otherwise it must be the bare name of a method or field.
+func (r *Registry) matchEntry(name, id string, e entry, members, fold bool) bool {
+	local := strings.TrimPrefix(id, e.pkg+".")
+	if members {
+		if e.kind != KindMethod && e.kind != KindField {
+			return false
+		}
+		return matchName(name, local[strings.LastIndexByte(local, '.')+1:], fold)
+	}
+
+	if matchName(name, local, fold) {
+		return true
+	}
+
+	// Qualified by package, "pkg.Local"
+	if len(name) <= len(local)+1 || name[len(name)-len(local)-1] != '.' {
+		return false
+	}
+	if !matchName(name[len(name)-len(local):], local, fold) {
+		return false
+	}
+	return matchPackage(name[:len(name)-len(local)-1], e.pkg, r.pkgs[e.pkg].Name, fold)
+}
+
+// matchPackage reports whether name refers to a package, by its name, its ID,
+// or a trailing part of its ID made of whole path elements.
+func matchPackage(name, id, pkgName string, fold bool) bool {
+	if matchName(name, pkgName, fold) || matchName(name, id, fold) {
+		return true
+	}
+	return len(id) > len(name) && id[len(id)-len(name)-1] == '/' && matchName(name, id[len(id)-len(name):], fold)
+}
+
+// matchName reports whether name matches target.
+// If fold is true, lowercase letters in name also match uppercase letters in target, as in go doc.
+func matchName(name, target string, fold bool) bool {
+	if !fold {
+		return name == target
+	}
+
+	for len(name) > 0 && len(target) > 0 {
+		nr, nsize := utf8.DecodeRuneInString(name)
+		tr, tsize := utf8.DecodeRuneInString(target)
+		if nr != tr && !(unicode.IsLower(nr) && unicode.ToLower(tr) == nr) {
+			return false
+		}
+		name, target = name[nsize:], target[tsize:]
+	}
+	return len(name) == 0 && len(target) == 0
+}
@@ -0,0 +1,95 @@
+package codoc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newResolveRegistry creates a registry with similarly named items for resolution tests
+func newResolveRegistry() *Registry {
+	r := NewRegistry()
+	r.Register(Package{
+		ID:   "github.com/noonien/codoc/codocgen",
+		Name: "codocgen",
+		Functions: map[string]Function{
+			"FromPath": {Name: "FromPath"},
+			"Exported": {Name: "Exported"},
+		},
+		Structs: map[string]Struct{
+			"PackageError": {
+				Name:    "PackageError",
+				Methods: map[string]Function{"Error": {Name: "Error"}},
+			},
+		},
+	})
+	r.Register(Package{
+		ID:   "example.com/other",
+		Name: "other",
+		Functions: map[string]Function{
+			"Exported": {Name: "Exported"},
+			"exported": {Name: "exported"},
+		},
+		Structs: map[string]Struct{
+			"Config": {
+				Name:   "Config",
+				Fields: map[string]Field{"Timeout": {Name: "Timeout"}},
+			},
+		},
+	})
+	return r
+}
+
+func TestResolve(t *testing.T) {
+	r := newResolveRegistry()
+
+	tests := []struct {
+		name string
+		id   string
+	}{
+		{"github.com/noonien/codoc/codocgen.FromPath", "github.com/noonien/codoc/codocgen.FromPath"},
+		{"codocgen.FromPath", "github.com/noonien/codoc/codocgen.FromPath"},
+		{"codoc/codocgen.FromPath", "github.com/noonien/codoc/codocgen.FromPath"},
+		{"FromPath", "github.com/noonien/codoc/codocgen.FromPath"},
+		{"frompath", "github.com/noonien/codoc/codocgen.FromPath"},
+		{"codocgen.packageerror.error", "github.com/noonien/codoc/codocgen.PackageError.Error"},
+		{"PackageError.Error", "github.com/noonien/codoc/codocgen.PackageError.Error"},
+		{"Error", "github.com/noonien/codoc/codocgen.PackageError.Error"},
+		{"timeout", "example.com/other.Config.Timeout"},
+		{"codocgen", "github.com/noonien/codoc/codocgen"},
+		{"other.exported", "example.com/other.exported"},
+		{"other.Exported", "example.com/other.Exported"},
+	}
+
+	for _, tt := range tests {
+		e, err := r.Resolve(tt.name)
+		if assert.NoError(t, err, "Resolve(%q) failed", tt.name) {
+			assert.Equal(t, tt.id, e.ID, "Resolve(%q) mismatch", tt.name)
+		}
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	r := newResolveRegistry()
+
+	_, err := r.Resolve("Exported")
+	var ambiguous *AmbiguousError
+	require.ErrorAs(t, err, &ambiguous, "Name in several packages should be ambiguous")
+	assert.Equal(t, []string{
+		"example.com/other.Exported",
+		"github.com/noonien/codoc/codocgen.Exported",
+	}, ambiguous.Candidates, "Candidates mismatch")
+	assert.Contains(t, err.Error(), "example.com/other.Exported", "Error should list the candidates")
+
+	// Lowercase names only fall back to case-insensitive matches when there is no exact one
+	_, err = r.Resolve("codocgen.exported")
+	assert.NoError(t, err, "Case-insensitive match should resolve")
+
+	_, err = r.Resolve("Missing")
+	assert.ErrorIs(t, err, ErrNotFound, "Unknown names should not be found")
+
+	// Uppercase letters only match uppercase ones
+	_, err = r.Resolve("FROMPATH")
+	assert.ErrorIs(t, err, ErrNotFound, "Uppercase letters should match exactly")
+}
@@ -0,0 +1,483 @@
+package codoc
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Term weights used when indexing documentation for search.
+// Matches in names weigh more than matches in the documentation text.
+const (
+	nameWeight     = 4.0 // Weight of a term found in an item's name
+	docWeight      = 1.0 // Weight of a term found in an item's documentation
+	prefixPenalty  = 0.5 // Factor applied to query terms matching only a prefix of an indexed term
+	minPrefixLen   = 3   // Minimum length of a query term to match indexed terms by prefix
+	snippetContext = 60  // Number of bytes of context kept around the first match in snippets
+)
+
+// SearchResult is an item found by Search.
+type SearchResult struct {
+	Entry
+	Score   float64 // Relevance of the result, higher is better
+	Snippet string  // Excerpt of the documentation with matched terms highlighted
+}
+
+// SearchOption is a function type that modifies a search.
+type SearchOption func(*searchConfig)
+
+// searchConfig holds the settings of a search.
+type searchConfig struct {
+	kinds    []Kind   // Kinds of items to return, all if empty
+	packages []string // IDs of packages to search in, all if empty
+	limit    int      // Maximum number of results, unlimited if zero
+	pre      string   // Marker inserted before highlighted terms
+	post     string   // Marker inserted after highlighted terms
+}
+
+// SearchKinds returns a SearchOption that only returns items of the given kinds.
+func SearchKinds(kinds ...Kind) SearchOption {
+	return func(c *searchConfig) {
+		c.kinds = append(c.kinds, kinds...)
+	}
+}
+
+// SearchPackages returns a SearchOption that only returns items from the packages with the given IDs.
+func SearchPackages(ids ...string) SearchOption {
+	return func(c *searchConfig) {
+		c.packages = append(c.packages, ids...)
+	}
+}
+
+// SearchLimit returns a SearchOption that limits the number of results.
+func SearchLimit(n int) SearchOption {
+	return func(c *searchConfig) {
+		c.limit = n
+	}
+}
+
+// SearchHighlight returns a SearchOption that sets the markers placed around matched terms in snippets.
+// The default markers are "**", as in Markdown bold text.
+func SearchHighlight(pre, post string) SearchOption {
+	return func(c *searchConfig) {
+		c.pre, c.post = pre, post
+	}
+}
+
+// Search searches the names and documentation in the default registry, see Registry.Search.
+func Search(query string, opts ...SearchOption) []SearchResult {
+	return Default.Search(query, opts...)
+}
+
+// Search searches the names and documentation of every registered item for the words in query.
+// Results are ranked by relevance: items matching more of the query words come first,
+// matches in names weigh more than matches in documentation, and rare words weigh more than
+// common ones. Query words of three or more letters also match longer words starting with them.
+// Matching is case-insensitive, and camel case names also match their individual words,
+// so "timeout" matches ReadTimeout.
+func (r *Registry) Search(query string, opts ...SearchOption) []SearchResult {
+	conf := &searchConfig{pre: "**", post: "**"}
+	for _, opt := range opts {
+		opt(conf)
+	}
+
+	qterms := uniqueTerms(tokenize(query))
+	if len(qterms) == 0 {
+		return nil
+	}
+
+	r.loadAll()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// Accumulate the score of each query term for every matching item
+	total := float64(len(r.search.docs))
+	scores := map[string]float64{}
+	matched := map[string]int{}
+	for _, qt := range qterms {
+		best := map[string]float64{}
+		r.search.match(qt, func(postings map[string]float64, factor float64) {
+			idf := math.Log(1 + total/float64(len(postings)))
+			for id, w := range postings {
+				if s := w * idf * factor; s > best[id] {
+					best[id] = s
+				}
+			}
+		})
+		for id, s := range best {
+			scores[id] += s
+			matched[id]++
+		}
+	}
+
+	var results []SearchResult
+	for id, score := range scores {
+		e, ok := r.entryLocked(id)
+		if !ok {
+			continue
+		}
+		if len(conf.kinds) > 0 && !hasKind(conf.kinds, e.Kind) {
+			continue
+		}
+		if len(conf.packages) > 0 && !hasString(conf.packages, e.Package) {
+			continue
+		}
+
+		// Favor items matching more of the query
+		score *= float64(matched[id]) / float64(len(qterms))
+		results = append(results, SearchResult{Entry: e, Score: score})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+	if conf.limit > 0 && len(results) > conf.limit {
+		results = results[:conf.limit]
+	}
+
+	// Only build snippets for the returned results
+	for i := range results {
+		text := orSynopsis(results[i].Doc, results[i].Synopsis)
+		results[i].Snippet = snippet(text, qterms, conf.pre, conf.post)
+	}
+
+	return results
+}
+
+// entryLocked returns the Entry of a package or indexed item. The caller must hold the lock.
+func (r *Registry) entryLocked(id string) (Entry, bool) {
+	if e, ok := r.index[id]; ok {
+		return e.entry(id), true
+	}
+	if pkg, ok := r.pkgs[id]; ok {
+		return pkgEntry(id, pkg), true
+	}
+	return Entry{}, false
+}
+
+// searchIndex is an inverted index of the terms in item names and documentation.
+type searchIndex struct {
+	terms  map[string]map[string]float64 // Term -> item ID -> weight
+	docs   map[string][]string           // Item ID -> indexed terms, for removal
+	sorted []string                      // Indexed terms in sorted order, for prefix matching
+}
+
+// newSearchIndex creates an empty search index.
+func newSearchIndex() searchIndex {
+	return searchIndex{
+		terms: map[string]map[string]float64{},
+		docs:  map[string][]string{},
+	}
+}
+
+// add indexes the weighted terms of items by ID, replacing any terms they had.
+func (s *searchIndex) add(items map[string]map[string]float64) {
+	ids := make([]string, 0, len(items))
+	for id := range items {
+		ids = append(ids, id)
+	}
+	s.remove(ids...)
+
+	var added []string
+	for id, terms := range items {
+		list := make([]string, 0, len(terms))
+		for term, w := range terms {
+			postings, ok := s.terms[term]
+			if !ok {
+				postings = map[string]float64{}
+				s.terms[term] = postings
+				added = append(added, term)
+			}
+			postings[id] = w
+			list = append(list, term)
+		}
+		s.docs[id] = list
+	}
+
+	// Merge the new terms into the sorted list in a single pass
+	sort.Strings(added)
+	merged := make([]string, 0, len(s.sorted)+len(added))
+	i, j := 0, 0
+	for i < len(s.sorted) && j < len(added) {
+		if s.sorted[i] < added[j] {
+			merged = append(merged, s.sorted[i])
+			i++
+		} else {
+			merged = append(merged, added[j])
+			j++
+		}
+	}
+	merged = append(merged, s.sorted[i:]...)
+	s.sorted = append(merged, added[j:]...)
+}
+
+// remove deletes items from the index.
+func (s *searchIndex) remove(ids ...string) {
+	dropped := map[string]bool{}
+	for _, id := range ids {
+		for _, term := range s.docs[id] {
+			postings := s.terms[term]
+			delete(postings, id)
+			if len(postings) == 0 {
+				delete(s.terms, term)
+				dropped[term] = true
+			}
+		}
+		delete(s.docs, id)
+	}
+	if len(dropped) == 0 {
+		return
+	}
+
+	kept := s.sorted[:0]
+	for _, term := range s.sorted {
+		if !dropped[term] {
+			kept = append(kept, term)
+		}
+	}
+	s.sorted = kept
+}
+
+// match calls fn with the postings of every indexed term matching a query term, along with
+// the factor applied to its scores. The query term itself is looked up directly, and terms
+// it is a prefix of are found by binary search in the sorted term list.
+func (s *searchIndex) match(qt string, fn func(postings map[string]float64, factor float64)) {
+	if postings, ok := s.terms[qt]; ok {
+		fn(postings, 1)
+	}
+	if len(qt) < minPrefixLen {
+		return
+	}
+
+	for i := sort.SearchStrings(s.sorted, qt); i < len(s.sorted) && strings.HasPrefix(s.sorted[i], qt); i++ {
+		if s.sorted[i] != qt {
+			fn(s.terms[s.sorted[i]], prefixPenalty)
+		}
+	}
+}
+
+// searchTerms computes the weighted search terms of a package and everything in it, by item ID.
+func searchTerms(id string, pkg Package) map[string]map[string]float64 {
+	docs := map[string]map[string]float64{}
+
+	pkgTerms := weighTerms(pkg.Name, orSynopsis(pkg.Doc, pkg.Synopsis))
+	for _, elem := range strings.Split(pkg.ID, "/") {
+		addTerms(pkgTerms, nameTerms(elem), nameWeight)
+	}
+	docs[id] = pkgTerms
+
+	prefix := id + "."
+	for name, fn := range pkg.Functions {
+		docs[prefix+name] = weighTerms(name, orSynopsis(fn.Doc, fn.Synopsis))
+	}
+	for name, st := range pkg.Structs {
+		stID := prefix + name
+		docs[stID] = weighTerms(name, orSynopsis(st.Doc, st.Synopsis))
+		for mname, m := range st.Methods {
+			docs[stID+"."+mname] = weighTerms(mname, orSynopsis(m.Doc, m.Synopsis))
+		}
+		for fname, f := range st.Fields {
+			docs[stID+"."+fname] = weighTerms(fname, f.Doc, orSynopsis(f.Comment, f.Synopsis))
+		}
+	}
+
+	return docs
+}
+
+// weighTerms computes the weighted terms of an item from its name and documentation texts.
+// Terms repeated in the documentation weigh logarithmically more.
+func weighTerms(name string, docs ...string) map[string]float64 {
+	terms := map[string]float64{}
+	addTerms(terms, nameTerms(name), nameWeight)
+
+	counts := map[string]int{}
+	for _, doc := range docs {
+		for _, term := range tokenize(doc) {
+			counts[term]++
+		}
+	}
+	for term, n := range counts {
+		terms[term] += docWeight * (1 + math.Log(float64(n)))
+	}
+
+	return terms
+}
+
+// orSynopsis returns doc, or synopsis if doc is empty, as is the case for docs generated with
+// only synopses. The synopsis is part of doc otherwise, and would count its words twice.
+func orSynopsis(doc, synopsis string) string {
+	if doc == "" {
+		return synopsis
+	}
+	return doc
+}
+
+// addTerms adds terms with the given weight, keeping the highest weight of duplicates.
+func addTerms(terms map[string]float64, list []string, weight float64) {
+	for _, term := range list {
+		if terms[term] < weight {
+			terms[term] = weight
+		}
+	}
+}
+
+// nameTerms splits an identifier into lowercase search terms.
+// The whole name is a term, as are the words of camel case names, like "read" and "timeout" for ReadTimeout.
+func nameTerms(name string) []string {
+	terms := tokenize(name)
+
+	// Split camel case words, keeping acronyms like "HTTP" in HTTPServer together
+	runes := []rune(name)
+	start := 0
+	for i := 1; i <= len(runes); i++ {
+		boundary := i == len(runes) || !isWordRune(runes[i]) ||
+			(unicode.IsUpper(runes[i]) && (unicode.IsLower(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))))
+		if !boundary {
+			continue
+		}
+		if word := strings.ToLower(string(runes[start:i])); len(word) > 0 && isWordRune(runes[start]) {
+			terms = append(terms, word)
+		}
+		start = i
+		if i < len(runes) && !isWordRune(runes[i]) {
+			start = i + 1
+		}
+	}
+
+	return uniqueTerms(terms)
+}
+
+// tokenize splits text into lowercase words made of letters and digits.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !isWordRune(r)
+	})
+}
+
+// isWordRune reports whether r is part of a word.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// uniqueTerms removes duplicate terms, keeping the first occurrence.
+func uniqueTerms(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	res := terms[:0]
+	for _, t := range terms {
+		if !seen[t] {
+			seen[t] = true
+			res = append(res, t)
+		}
+	}
+	return res
+}
+
+// snippet returns an excerpt of text around the first word matching one of the query terms,
+// with every matching word wrapped in the pre and post markers.
+func snippet(text string, qterms []string, pre, post string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if text == "" {
+		return ""
+	}
+
+	// Find the words of the text along with their positions
+	type word struct{ start, end int }
+	var words []word
+	start := -1
+	for i, r := range text {
+		if isWordRune(r) {
+			if start == -1 {
+				start = i
+			}
+			continue
+		}
+		if start != -1 {
+			words = append(words, word{start, i})
+			start = -1
+		}
+	}
+	if start != -1 {
+		words = append(words, word{start, len(text)})
+	}
+
+	matches := func(w string) bool {
+		w = strings.ToLower(w)
+		for _, qt := range qterms {
+			if w == qt || (len(qt) >= minPrefixLen && strings.HasPrefix(w, qt)) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var hits []word
+	for _, w := range words {
+		if matches(text[w.start:w.end]) {
+			hits = append(hits, w)
+		}
+	}
+
+	// Cut the text around the first match, on word boundaries
+	from, to, minTo := 0, 3*snippetContext, 0
+	if len(hits) > 0 {
+		from = hits[0].start - snippetContext
+		to = hits[0].end + 2*snippetContext
+		minTo = hits[0].end
+	}
+	if from <= 0 {
+		from = 0
+	} else if i := strings.IndexByte(text[from:], ' '); i != -1 && from+i < hits[0].start {
+		from += i + 1
+	} else {
+		for !utf8.RuneStart(text[from]) {
+			from++
+		}
+	}
+	if to >= len(text) {
+		to = len(text)
+	} else if i := strings.LastIndexByte(text[:to], ' '); i > from && i >= minTo {
+		to = i
+	} else {
+		for to < len(text) && !utf8.RuneStart(text[to]) {
+			to++
+		}
+	}
+
+	var sb strings.Builder
+	if from > 0 {
+		sb.WriteString("…")
+	}
+	pos := from
+	for _, h := range hits {
+		if h.start < from || h.end > to {
+			continue
+		}
+		sb.WriteString(text[pos:h.start])
+		sb.WriteString(pre)
+		sb.WriteString(text[h.start:h.end])
+		sb.WriteString(post)
+		pos = h.end
+	}
+	sb.WriteString(text[pos:to])
+	if to < len(text) {
+		sb.WriteString("…")
+	}
+
+	return sb.String()
+}
+
+// hasString reports whether list contains s.
+func hasString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
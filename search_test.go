@@ -0,0 +1,175 @@
+package codoc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSearchRegistry creates a registry with documentation to search
+func newSearchRegistry() *Registry {
+	r := NewRegistry()
+	r.Register(Package{
+		ID:   "example.com/net/server",
+		Name: "server",
+		Doc:  "Package server implements a small HTTP server.",
+		Functions: map[string]Function{
+			"Listen": {Name: "Listen", Doc: "Listen starts accepting connections on the given address."},
+			"Dial":   {Name: "Dial", Doc: "Dial connects to an address. The dial fails if the timeout expires."},
+		},
+		Structs: map[string]Struct{
+			"Config": {
+				Name: "Config",
+				Doc:  "Config holds the server settings.",
+				Fields: map[string]Field{
+					"ReadTimeout": {Name: "ReadTimeout", Comment: "Maximum duration for reading a request"},
+					"Addr":        {Name: "Addr", Doc: "Addr is the address to listen on."},
+				},
+				Methods: map[string]Function{
+					"Validate": {Name: "Validate", Doc: "Validate checks the timeout settings."},
+				},
+			},
+		},
+	})
+	r.Register(Package{
+		ID:   "example.com/client",
+		Name: "client",
+		Functions: map[string]Function{
+			"Get": {Name: "Get", Doc: "Get fetches a page, giving up after a timeout."},
+		},
+	})
+	return r
+}
+
+func TestSearchRanking(t *testing.T) {
+	r := newSearchRegistry()
+
+	results := r.Search("timeout")
+	require.NotEmpty(t, results, "Search should find matches")
+
+	// Name matches rank above documentation matches
+	assert.Equal(t, "example.com/net/server.Config.ReadTimeout", results[0].ID, "Name match should rank first")
+	assert.Equal(t, KindField, results[0].Kind, "Result kind mismatch")
+
+	var ids []string
+	for _, res := range results {
+		ids = append(ids, res.ID)
+	}
+	assert.ElementsMatch(t, []string{
+		"example.com/net/server.Config.ReadTimeout",
+		"example.com/net/server.Dial",
+		"example.com/net/server.Config.Validate",
+		"example.com/client.Get",
+	}, ids, "Search results mismatch")
+
+	// Items matching more of the query rank higher
+	results = r.Search("timeout settings")
+	require.NotEmpty(t, results, "Search should find matches")
+	assert.Equal(t, "example.com/net/server.Config.Validate", results[0].ID, "Best match should rank first")
+
+	// Case-insensitive, with prefix matching
+	results = r.Search("LISTEN")
+	require.NotEmpty(t, results, "Search should be case-insensitive")
+	assert.Equal(t, "example.com/net/server.Listen", results[0].ID, "Name match should rank first")
+
+	results = r.Search("accept")
+	require.Len(t, results, 1, "Prefix should match longer words")
+	assert.Equal(t, "example.com/net/server.Listen", results[0].ID, "Prefix match mismatch")
+
+	assert.Empty(t, r.Search("nothing"), "Unknown words should not match")
+	assert.Empty(t, r.Search("  "), "Empty queries should not match")
+}
+
+func TestSearchOptions(t *testing.T) {
+	r := newSearchRegistry()
+
+	results := r.Search("timeout", SearchKinds(KindFunction))
+	for _, res := range results {
+		assert.Equal(t, KindFunction, res.Kind, "Only functions should be returned")
+	}
+	assert.Len(t, results, 2, "Both functions should be found")
+
+	results = r.Search("timeout", SearchPackages("example.com/client"))
+	require.Len(t, results, 1, "Search should be scoped to the package")
+	assert.Equal(t, "example.com/client.Get", results[0].ID, "Scoped result mismatch")
+
+	assert.Len(t, r.Search("timeout", SearchLimit(2)), 2, "Results should be limited")
+
+	results = r.Search("server", SearchKinds(KindPackage))
+	require.Len(t, results, 1, "Packages should be searchable")
+	assert.Equal(t, "example.com/net/server", results[0].ID, "Package result mismatch")
+}
+
+func TestSearchSnippets(t *testing.T) {
+	r := newSearchRegistry()
+
+	results := r.Search("timeout", SearchPackages("example.com/client"))
+	require.Len(t, results, 1, "Search should find the function")
+	assert.Equal(t, "Get fetches a page, giving up after a **timeout**.", results[0].Snippet, "Snippet mismatch")
+
+	results = r.Search("dial", SearchHighlight("<b>", "</b>"))
+	require.NotEmpty(t, results, "Search should find the function")
+	assert.Equal(t, "<b>Dial</b> connects to an address. The <b>dial</b> fails if the timeout expires.",
+		results[0].Snippet, "Snippet with custom highlight mismatch")
+
+	// Long texts are cut around the first match
+	long := "Lorem ipsum dolor sit amet, consectetur adipiscing elit, sed do eiusmod tempor incididunt ut labore " +
+		"et dolore magna aliqua. The needle is here. Ut enim ad minim veniam, quis nostrud exercitation ullamco " +
+		"laboris nisi ut aliquip ex ea commodo consequat. Duis aute irure dolor in reprehenderit in voluptate " +
+		"velit esse cillum dolore eu fugiat nulla pariatur."
+	s := snippet(long, []string{"needle"}, "[", "]")
+	assert.Contains(t, s, "[needle]", "Snippet should highlight the match")
+	assert.True(t, len(s) < len(long), "Snippet should be shorter than the text")
+	assert.Regexp(t, "^….*…$", s, "Cut snippets should be marked with ellipses")
+}
+
+func TestSearchAfterUnregister(t *testing.T) {
+	r := newSearchRegistry()
+	require.NotEmpty(t, r.Search("fetches"), "Search should find the function")
+
+	r.Unregister("example.com/client")
+	assert.Empty(t, r.Search("fetches"), "Unregistered items should not be found")
+
+	r.Replace(Package{
+		ID:   "example.com/net/server",
+		Name: "server",
+		Functions: map[string]Function{
+			"Serve": {Name: "Serve", Doc: "Serve handles requests."},
+		},
+	})
+	assert.Empty(t, r.Search("listen"), "Replaced items should not be found")
+	assert.NotEmpty(t, r.Search("requests"), "Replacing items should be found")
+}
+
+func TestSearchIndexSorted(t *testing.T) {
+	r := newSearchRegistry()
+	assertSortedTerms := func(msg string) {
+		t.Helper()
+		assert.Equal(t, sortedKeys(r.search.terms), r.search.sorted, msg)
+	}
+	assertSortedTerms("Terms should be sorted after registering")
+
+	r.Unregister("example.com/client")
+	assertSortedTerms("Terms should be sorted after unregistering")
+	assert.Empty(t, r.Search("fetch"), "Removed terms should not match by prefix")
+
+	r.Replace(Package{
+		ID:   "example.com/net/server",
+		Name: "server",
+		Functions: map[string]Function{
+			"Connect": {Name: "Connect", Doc: "Connect opens a connection."},
+		},
+	})
+	assertSortedTerms("Terms should be sorted after replacing")
+
+	results := r.Search("conn")
+	require.Len(t, results, 1, "Prefixes should match through the sorted terms")
+	assert.Equal(t, "example.com/net/server.Connect", results[0].ID)
+}
+
+func TestNameTerms(t *testing.T) {
+	assert.Equal(t, []string{"readtimeout", "read", "timeout"}, nameTerms("ReadTimeout"), "Camel case terms mismatch")
+	assert.Equal(t, []string{"httpserver", "http", "server"}, nameTerms("HTTPServer"), "Acronym terms mismatch")
+	assert.Equal(t, []string{"read", "timeout"}, nameTerms("read_timeout"), "Snake case terms mismatch")
+}
@@ -0,0 +1,122 @@
+package codoc
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Suggestion is a registered item whose name is close to a looked up name.
+type Suggestion struct {
+	Entry
+	Distance int // Edit distance between the looked up name and the closest name of the item
+}
+
+// Suggest returns up to n items of the default registry with names close to name, see Registry.Suggest.
+func Suggest(name string, n int) []Suggestion { return Default.Suggest(name, n) }
+
+// Suggest returns up to n registered items with names close to name, closest first,
+// for "did you mean" messages when a lookup fails.
+// Names are compared case-insensitively by edit distance against the full ID of each item,
+// its ID qualified by package name instead of import path, like "codocgen.FromPath",
+// its ID within the package, like "Struct.Method", and its bare name.
+// Only items within an edit distance of a third of the length of name, and at least 2,
+// are returned. n <= 0 returns all of them.
+func (r *Registry) Suggest(name string, n int) []Suggestion {
+	query := strings.ToLower(name)
+	if query == "" {
+		return nil
+	}
+	maxDist := utf8.RuneCountInString(query) / 3
+	if maxDist < 2 {
+		maxDist = 2
+	}
+
+	r.loadAll()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var res []Suggestion
+	consider := func(e Entry, names ...string) {
+		best := -1
+		for _, cand := range names {
+			if cand == "" {
+				continue
+			}
+			d := editDistance(query, strings.ToLower(cand), maxDist)
+			if d <= maxDist && (best == -1 || d < best) {
+				best = d
+			}
+		}
+		if best != -1 {
+			res = append(res, Suggestion{Entry: e, Distance: best})
+		}
+	}
+
+	for id, pkg := range r.pkgs {
+		consider(pkgEntry(id, pkg), id, pkg.Name)
+	}
+	for id, e := range r.index {
+		local := strings.TrimPrefix(id, e.pkg+".")
+		ent := e.entry(id)
+		consider(ent, id, r.pkgs[e.pkg].Name+"."+local, local, ent.Name)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Distance != res[j].Distance {
+			return res[i].Distance < res[j].Distance
+		}
+		return res[i].ID < res[j].ID
+	})
+	if n > 0 && len(res) > n {
+		res = res[:n]
+	}
+
+	return res
+}
+
+// editDistance returns the Levenshtein distance between a and b, counted in runes.
+// Computation stops early once the distance is known to exceed limit, returning limit+1.
+func editDistance(a, b string, limit int) int {
+	ra, rb := []rune(a), []rune(b)
+	if d := len(ra) - len(rb); d > limit || -d > limit {
+		return limit + 1
+	}
+
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if cur[j] < rowMin {
+				rowMin = cur[j]
+			}
+		}
+		if rowMin > limit {
+			return limit + 1
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(rb)]
+}
+
+// minInt returns the smallest of the given ints.
+func minInt(v int, vs ...int) int {
+	for _, x := range vs {
+		if x < v {
+			v = x
+		}
+	}
+	return v
+}
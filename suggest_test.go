@@ -0,0 +1,59 @@
+package codoc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("same", "same", 5), "Equal strings distance mismatch")
+	assert.Equal(t, 3, editDistance("kitten", "sitting", 5), "Distance mismatch")
+	assert.Equal(t, 1, editDistance("héllo", "hello", 5), "Distance should count runes")
+	assert.Equal(t, 3, editDistance("a", "abcdef", 2), "Distance over max should be max+1")
+}
+
+func TestSuggest(t *testing.T) {
+	r := NewRegistry()
+	r.Register(Package{
+		ID:   "github.com/noonien/codoc/codocgen",
+		Name: "codocgen",
+		Functions: map[string]Function{
+			"FromPath":     {Name: "FromPath"},
+			"RegisterPath": {Name: "RegisterPath"},
+		},
+		Structs: map[string]Struct{
+			"PackageError": {
+				Name:    "PackageError",
+				Methods: map[string]Function{"Error": {Name: "Error"}},
+			},
+		},
+	})
+
+	// Misspelled bare names
+	res := r.Suggest("FromPth", 3)
+	require.NotEmpty(t, res, "Suggest should find close names")
+	assert.Equal(t, "github.com/noonien/codoc/codocgen.FromPath", res[0].ID, "Closest suggestion mismatch")
+	assert.Equal(t, 1, res[0].Distance, "Distance mismatch")
+
+	// Case-insensitive, package name qualified
+	res = r.Suggest("CODOCGEN.frompath", 3)
+	require.NotEmpty(t, res, "Suggest should be case-insensitive")
+	assert.Equal(t, "github.com/noonien/codoc/codocgen.FromPath", res[0].ID, "Closest suggestion mismatch")
+	assert.Equal(t, 0, res[0].Distance, "Distance mismatch")
+
+	// Methods by their ID within the package
+	res = r.Suggest("PackageErr.Error", 3)
+	require.NotEmpty(t, res, "Suggest should match methods")
+	assert.Equal(t, "github.com/noonien/codoc/codocgen.PackageError.Error", res[0].ID, "Closest suggestion mismatch")
+	assert.Equal(t, KindMethod, res[0].Kind, "Suggestion kind mismatch")
+
+	// Packages by name
+	res = r.Suggest("codcgen", 1)
+	require.Len(t, res, 1, "Suggest should respect the limit")
+	assert.Equal(t, KindPackage, res[0].Kind, "Suggestion kind mismatch")
+
+	assert.Empty(t, r.Suggest("CompletelyDifferent", 3), "Distant names should not be suggested")
+	assert.Empty(t, r.Suggest("", 3), "Empty names should not be suggested")
+}
@@ -0,0 +1,220 @@
+package codoc
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// Entry describes an item in a registry, independently of its kind.
+type Entry struct {
+	ID       string // ID of the item, like "pkg.Struct.Method"
+	Kind     Kind   // Kind of the item
+	Package  string // ID of the package the item belongs to
+	Struct   string // ID of the struct for methods and fields, empty otherwise
+	Name     string // Name of the item, the last element of its ID
+	Doc      string // Documentation string
+	Synopsis string // First sentence of the documentation
+}
+
+// SkipChildren can be returned by a WalkFunc visiting a package or struct to skip
+// its contents. Returned for any other entry, it skips the rest of the enclosing
+// package or struct.
+var SkipChildren = errors.New("skip children")
+
+// SkipAll can be returned by a WalkFunc to stop the walk without an error.
+var SkipAll = errors.New("skip everything")
+
+// WalkFunc is called by Walk for every visited entry.
+// Returning SkipChildren or SkipAll changes the walk as documented on them,
+// returning any other error stops the walk and makes Walk return it.
+type WalkFunc func(e Entry) error
+
+// Packages returns all packages in the default registry, sorted by ID.
+func Packages() []Package { return Default.Packages() }
+
+// Entries returns the functions, structs, methods and fields in the default registry, sorted by ID.
+// If kinds are given, only entries of those kinds are returned, see Registry.Entries.
+func Entries(kinds ...Kind) []Entry { return Default.Entries(kinds...) }
+
+// Walk traverses the default registry, see Registry.Walk.
+func Walk(fn WalkFunc) error { return Default.Walk(fn) }
+
+// Packages returns all packages in the registry, sorted by ID.
+func (r *Registry) Packages() []Package {
+	r.loadAll()
+
+	r.mu.RLock()
+	ids := sortedKeys(r.pkgs)
+	pkgs := make([]Package, len(ids))
+	for i, id := range ids {
+		pkgs[i] = r.pkgs[id]
+	}
+	r.mu.RUnlock()
+
+	return pkgs
+}
+
+// Entries returns the functions, structs, methods and fields in the registry, sorted by ID.
+// If kinds are given, only entries of those kinds are returned. Packages are only returned
+// when KindPackage is one of them.
+func (r *Registry) Entries(kinds ...Kind) []Entry {
+	r.loadAll()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var entries []Entry
+	if hasKind(kinds, KindPackage) {
+		for id, pkg := range r.pkgs {
+			entries = append(entries, pkgEntry(id, pkg))
+		}
+	}
+	for id, e := range r.index {
+		if len(kinds) > 0 && !hasKind(kinds, e.kind) {
+			continue
+		}
+		entries = append(entries, e.entry(id))
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+
+	return entries
+}
+
+// Walk traverses the registry, calling fn for each package, followed by its functions,
+// then its structs, each followed by its fields and then its methods. Items of the same
+// kind are visited in order of their IDs.
+// Walk works on a snapshot of the registry, so fn may use the registry, including to
+// modify it, without affecting the walk.
+func (r *Registry) Walk(fn WalkFunc) error {
+	err := walkPackages(r.Packages(), fn)
+	if err == SkipAll {
+		return nil
+	}
+	return err
+}
+
+// walkPackages calls fn for each package and its contents.
+func walkPackages(pkgs []Package, fn WalkFunc) error {
+	for _, pkg := range pkgs {
+		id := pkgID(pkg)
+		err := fn(pkgEntry(id, pkg))
+		if err == SkipChildren {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := walkPackage(id, pkg, fn); err != nil && err != SkipChildren {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkPackage calls fn for the functions and structs of a package.
+func walkPackage(id string, pkg Package, fn WalkFunc) error {
+	prefix := id + "."
+	for _, name := range sortedKeys(pkg.Functions) {
+		if err := fn(funcEntry(prefix+name, KindFunction, id, "", pkg.Functions[name])); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range sortedKeys(pkg.Structs) {
+		st := pkg.Structs[name]
+		stID := prefix + name
+		err := fn(structEntry(stID, id, st))
+		if err == SkipChildren {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := walkStruct(stID, id, st, fn); err != nil && err != SkipChildren {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkStruct calls fn for the fields and methods of a struct.
+func walkStruct(stID, pkg string, st Struct, fn WalkFunc) error {
+	for _, name := range sortedKeys(st.Fields) {
+		if err := fn(fieldEntry(stID+"."+name, pkg, stID, st.Fields[name])); err != nil {
+			return err
+		}
+	}
+	for _, name := range sortedKeys(st.Methods) {
+		if err := fn(funcEntry(stID+"."+name, KindMethod, pkg, stID, st.Methods[name])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// entry converts an index entry to an Entry.
+func (e entry) entry(id string) Entry {
+	switch e.kind {
+	case KindStruct:
+		return structEntry(id, e.pkg, e.st)
+	case KindField:
+		return fieldEntry(id, e.pkg, id[:strings.LastIndexByte(id, '.')], e.field)
+	case KindMethod:
+		return funcEntry(id, e.kind, e.pkg, id[:strings.LastIndexByte(id, '.')], e.fn)
+	}
+	return funcEntry(id, e.kind, e.pkg, "", e.fn)
+}
+
+// pkgEntry builds the Entry of a package.
+func pkgEntry(id string, pkg Package) Entry {
+	return Entry{ID: id, Kind: KindPackage, Package: id, Name: pkg.Name, Doc: pkg.Doc, Synopsis: pkg.Synopsis}
+}
+
+// funcEntry builds the Entry of a function or method.
+func funcEntry(id string, kind Kind, pkg, st string, fn Function) Entry {
+	return Entry{ID: id, Kind: kind, Package: pkg, Struct: st, Name: itemName(id), Doc: fn.Doc, Synopsis: fn.Synopsis}
+}
+
+// structEntry builds the Entry of a struct.
+func structEntry(id, pkg string, st Struct) Entry {
+	return Entry{ID: id, Kind: KindStruct, Package: pkg, Name: itemName(id), Doc: st.Doc, Synopsis: st.Synopsis}
+}
+
+// fieldEntry builds the Entry of a struct field.
+// Fields with no documentation use their inline comment instead.
+func fieldEntry(id, pkg, st string, f Field) Entry {
+	doc := f.Doc
+	if doc == "" {
+		doc = f.Comment
+	}
+	return Entry{ID: id, Kind: KindField, Package: pkg, Struct: st, Name: itemName(id), Doc: doc, Synopsis: f.Synopsis}
+}
+
+// itemName returns the name of a function, struct, method or field from its ID.
+// Items are registered under their map keys, which are used even if their Name is not set.
+func itemName(id string) string {
+	return id[strings.LastIndexByte(id, '.')+1:]
+}
+
+// hasKind reports whether kinds contains k.
+func hasKind(kinds []Kind, k Kind) bool {
+	for _, kind := range kinds {
+		if kind == k {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedKeys returns the keys of a map in sorted order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
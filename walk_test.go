@@ -0,0 +1,155 @@
+package codoc
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newWalkRegistry creates a registry with two small packages for walking tests
+func newWalkRegistry() *Registry {
+	r := NewRegistry()
+	r.Register(Package{
+		ID:   "example.com/b",
+		Name: "b",
+		Functions: map[string]Function{
+			"Func": {Name: "Func", Doc: "Func documentation"},
+		},
+	})
+	r.Register(Package{
+		ID:   "example.com/a",
+		Name: "a",
+		Doc:  "Package a documentation",
+		Functions: map[string]Function{
+			"Zeta":  {Name: "Zeta"},
+			"Alpha": {Name: "Alpha"},
+		},
+		Structs: map[string]Struct{
+			"Struct": {
+				Name: "Struct",
+				Fields: map[string]Field{
+					"Field": {Name: "Field", Comment: "Field comment"},
+				},
+				Methods: map[string]Function{
+					"Method": {Name: "Method"},
+				},
+			},
+		},
+	})
+	return r
+}
+
+func TestPackagesAndEntries(t *testing.T) {
+	r := newWalkRegistry()
+
+	pkgs := r.Packages()
+	require.Len(t, pkgs, 2, "Packages should list every package")
+	assert.Equal(t, "example.com/a", pkgs[0].ID, "Packages should be sorted by ID")
+	assert.Equal(t, "example.com/b", pkgs[1].ID, "Packages should be sorted by ID")
+
+	var ids []string
+	for _, e := range r.Entries() {
+		ids = append(ids, e.ID)
+	}
+	assert.Equal(t, []string{
+		"example.com/a.Alpha",
+		"example.com/a.Struct",
+		"example.com/a.Struct.Field",
+		"example.com/a.Struct.Method",
+		"example.com/a.Zeta",
+		"example.com/b.Func",
+	}, ids, "Entries should be sorted by ID")
+
+	methods := r.Entries(KindMethod, KindField)
+	require.Len(t, methods, 2, "Entries should filter by kind")
+	assert.Equal(t, Entry{
+		ID:      "example.com/a.Struct.Field",
+		Kind:    KindField,
+		Package: "example.com/a",
+		Struct:  "example.com/a.Struct",
+		Name:    "Field",
+		Doc:     "Field comment",
+	}, methods[0], "Field entry mismatch")
+	assert.Equal(t, "example.com/a.Struct", methods[1].Struct, "Method struct mismatch")
+
+	ids = nil
+	for _, e := range r.Entries(KindPackage, KindFunction) {
+		ids = append(ids, e.ID)
+	}
+	assert.Equal(t, []string{
+		"example.com/a",
+		"example.com/a.Alpha",
+		"example.com/a.Zeta",
+		"example.com/b",
+		"example.com/b.Func",
+	}, ids, "Packages should be returned when requested")
+
+	pkgEntries := r.Entries(KindPackage)
+	require.Len(t, pkgEntries, 2, "Entries should return packages")
+	assert.Equal(t, KindPackage, pkgEntries[0].Kind)
+	assert.Equal(t, "example.com/a", pkgEntries[0].Package)
+}
+
+func TestWalk(t *testing.T) {
+	r := newWalkRegistry()
+
+	var visited []string
+	err := r.Walk(func(e Entry) error {
+		visited = append(visited, e.Kind.String()+" "+e.ID)
+		return nil
+	})
+	require.NoError(t, err, "Walk should not fail")
+	assert.Equal(t, []string{
+		"package example.com/a",
+		"function example.com/a.Alpha",
+		"function example.com/a.Zeta",
+		"struct example.com/a.Struct",
+		"field example.com/a.Struct.Field",
+		"method example.com/a.Struct.Method",
+		"package example.com/b",
+		"function example.com/b.Func",
+	}, visited, "Walk order mismatch")
+
+	// SkipChildren on a package skips its contents
+	visited = nil
+	err = r.Walk(func(e Entry) error {
+		visited = append(visited, e.ID)
+		if e.ID == "example.com/a" {
+			return SkipChildren
+		}
+		return nil
+	})
+	require.NoError(t, err, "Walk should not fail")
+	assert.Equal(t, []string{"example.com/a", "example.com/b", "example.com/b.Func"}, visited, "SkipChildren mismatch")
+
+	// SkipAll stops the walk without an error
+	visited = nil
+	err = r.Walk(func(e Entry) error {
+		visited = append(visited, e.ID)
+		return SkipAll
+	})
+	require.NoError(t, err, "SkipAll should not be returned")
+	assert.Equal(t, []string{"example.com/a"}, visited, "SkipAll mismatch")
+
+	// Other errors are returned as is
+	errStop := errors.New("stop")
+	err = r.Walk(func(e Entry) error {
+		if e.Kind == KindField {
+			return errStop
+		}
+		return nil
+	})
+	assert.Equal(t, errStop, err, "Walk should return the callback error")
+
+	// The walk works on a snapshot, so the registry can be modified from the callback
+	err = r.Walk(func(e Entry) error {
+		if e.Kind == KindPackage {
+			r.Unregister(e.ID)
+		}
+		return nil
+	})
+	require.NoError(t, err, "Walk should not fail")
+	assert.Empty(t, r.Packages(), "All packages should have been unregistered")
+}